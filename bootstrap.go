@@ -0,0 +1,50 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/linuxfoundation-it/meek-stv/bootstrap"
+)
+
+func bootstrapCmd(args []string, env *env) int {
+	fs := newFlagSet("bootstrap", "[ballot file]", "Recounts samples of the ballots drawn with replacement and reports how often each candidate wins.", env)
+	cf := addCountFlags(fs)
+	iterations := fs.Int("iterations", bootstrap.DefaultIterations, "number of resampled recounts")
+	seed := fs.Uint64("seed", 1, "random seed, the same seed reproduces the same result")
+	workers := fs.Int("workers", 0, "number of parallel recounts (default: number of CPUs)")
+	format := fs.String("format", "json", "output format: json or csv")
+
+	positional, err := parseFlags(fs, args)
+	if err != nil {
+		return usageError(err)
+	}
+	if len(positional) > 1 || *cf.seats < 0 {
+		fs.Usage()
+		return exitUsage
+	}
+	if *format != "json" && *format != "csv" {
+		fmt.Fprintf(env.stderr, "unknown output format %q\n", *format)
+		return exitUsage
+	}
+
+	e, code := cf.load(first(positional), env)
+	if e == nil {
+		return code
+	}
+
+	res, err := bootstrap.Run(e, bootstrap.Options{Iterations: *iterations, Seed: *seed, Workers: *workers})
+	if err != nil {
+		fmt.Fprintln(env.stderr, err)
+		return exitFailure
+	}
+	if *format == "csv" {
+		err = res.WriteCSV(env.stdout)
+	} else {
+		err = res.WriteJSON(env.stdout)
+	}
+	if err != nil {
+		fmt.Fprintln(env.stderr, err)
+		return exitFailure
+	}
+	return exitOK
+}
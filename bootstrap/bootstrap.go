@@ -0,0 +1,189 @@
+// Package bootstrap estimates how stable the outcome of a MeekSTV count is by
+// recounting ballot samples drawn with replacement from the original ballots.
+package bootstrap
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"runtime"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/linuxfoundation-it/meek-stv/election"
+	"github.com/linuxfoundation-it/meek-stv/meekstv"
+)
+
+const DefaultIterations = 1000
+
+// maxBallots bounds the number of ballots drawn in each recount
+const maxBallots = 1 << 53
+
+type Options struct {
+	// Iterations is the number of resampled recounts. Defaults to DefaultIterations.
+	Iterations int
+	// Seed makes the run reproducible. The same seed always yields the same result,
+	// independently of the number of workers.
+	Seed uint64
+	// Workers is the number of recounts run in parallel. Defaults to GOMAXPROCS.
+	Workers int
+}
+
+type Result struct {
+	Title      string            `json:"title"`
+	Seats      int               `json:"seats"`
+	Ballots    int               `json:"ballots"`
+	Iterations int               `json:"iterations"`
+	Seed       uint64            `json:"seed"`
+	Candidates []CandidateResult `json:"candidates"`
+}
+
+type CandidateResult struct {
+	Index int    `json:"index"`
+	Name  string `json:"name"`
+	// Elected reports whether the candidate won a seat in the deterministic count
+	Elected bool `json:"elected"`
+	// Wins is the number of resampled recounts in which the candidate won a seat
+	Wins      int     `json:"wins"`
+	Frequency float64 `json:"frequency"`
+}
+
+// Run recounts opts.Iterations samples of the ballots in e, each with as many
+// ballots as the original election, and tallies how often each candidate is elected.
+// Ballots are drawn one by one, so that their weights must be whole numbers of ballots.
+func Run(e *election.Election, opts Options) (*Result, error) {
+	if opts.Iterations <= 0 {
+		opts.Iterations = DefaultIterations
+	}
+	if opts.Workers <= 0 {
+		opts.Workers = runtime.GOMAXPROCS(0)
+	}
+
+	// cumulative weights, so that a ballot line is drawn with probability
+	// proportional to the number of physical ballots it stands for
+	cumulative := make([]int, len(e.Ballots))
+	total := 0
+	for i, b := range e.Ballots {
+		n, ok := b.Weight.Int()
+		if !ok || n > int64(maxBallots-total) {
+			return nil, fmt.Errorf("ballot %d: weight %s is not a number of ballots that can be drawn", i, b.Weight)
+		}
+		total += int(n)
+		cumulative[i] = total
+	}
+
+	wins := make([][]int, opts.Workers)
+	iterations := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < opts.Workers; w++ {
+		wins[w] = make([]int, e.Candidates)
+		wg.Add(1)
+		go func(tally []int) {
+			defer wg.Done()
+			for i := range iterations {
+				// seeding each iteration on its own makes results independent of scheduling
+				rng := rand.New(rand.NewPCG(opts.Seed, uint64(i)))
+				report := meekstv.Count(resample(e, cumulative, total, rng))
+				for _, idx := range report.Winners() {
+					tally[idx]++
+				}
+			}
+		}(wins[w])
+	}
+	for i := 0; i < opts.Iterations; i++ {
+		iterations <- i
+	}
+	close(iterations)
+	wg.Wait()
+
+	elected := make(map[int]bool)
+	if total > 0 {
+		report := meekstv.Count(e)
+		for _, idx := range report.Winners() {
+			elected[idx] = true
+		}
+	}
+
+	res := &Result{
+		Title:      e.Title,
+		Seats:      e.Seats,
+		Ballots:    total,
+		Iterations: opts.Iterations,
+		Seed:       opts.Seed,
+		Candidates: make([]CandidateResult, e.Candidates),
+	}
+	for i := range res.Candidates {
+		n := 0
+		for _, tally := range wins {
+			n += tally[i]
+		}
+		res.Candidates[i] = CandidateResult{
+			Index:     i,
+			Name:      e.CandidateNames[i],
+			Elected:   elected[i],
+			Wins:      n,
+			Frequency: float64(n) / float64(opts.Iterations),
+		}
+	}
+	return res, nil
+}
+
+// resample draws total ballots with replacement and returns them as a new election
+// where identical draws are aggregated into the weight of the original ballot line.
+func resample(e *election.Election, cumulative []int, total int, rng *rand.Rand) *election.Election {
+	counts := make([]int, len(e.Ballots))
+	for n := 0; n < total; n++ {
+		counts[sort.SearchInts(cumulative, rng.IntN(total)+1)]++
+	}
+
+	ballots := make([]election.Ballot, 0, len(e.Ballots))
+	for i, b := range e.Ballots {
+		if counts[i] == 0 {
+			continue
+		}
+		ballots = append(ballots, election.Ballot{Weight: election.NewWeight(int64(counts[i])), Preferences: b.Preferences, Equal: b.Equal})
+	}
+
+	sample := *e
+	sample.Ballots = ballots
+	return &sample
+}
+
+// Sorted returns the candidate results ordered by descending win frequency.
+func (r *Result) Sorted() []CandidateResult {
+	out := append([]CandidateResult(nil), r.Candidates...)
+	sort.SliceStable(out, func(i, j int) bool {
+		return out[i].Wins > out[j].Wins
+	})
+	return out
+}
+
+func (r *Result) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+func (r *Result) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"index", "name", "elected", "wins", "frequency"}); err != nil {
+		return err
+	}
+	for _, c := range r.Sorted() {
+		err := cw.Write([]string{
+			strconv.Itoa(c.Index),
+			c.Name,
+			strconv.FormatBool(c.Elected),
+			strconv.Itoa(c.Wins),
+			strconv.FormatFloat(c.Frequency, 'f', 4, 64),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
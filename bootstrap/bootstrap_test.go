@@ -0,0 +1,74 @@
+package bootstrap
+
+import (
+	"bytes"
+	"encoding/csv"
+	"os"
+	"testing"
+
+	"github.com/linuxfoundation-it/meek-stv/election"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func readBallots(t *testing.T, name string) *election.Election {
+	t.Helper()
+	f, err := os.Open("../testdata/" + name + ".txt")
+	require.NoError(t, err)
+	return election.Read(f)
+}
+
+func TestRun(t *testing.T) {
+	e := readBallots(t, "election13")
+
+	got, err := Run(e, Options{Iterations: 50, Seed: 42, Workers: 4})
+	require.NoError(t, err)
+
+	assert.Equal(t, 50, got.Iterations)
+	assert.Len(t, got.Candidates, e.Candidates)
+
+	total := 0
+	for _, c := range got.Candidates {
+		total += c.Wins
+		assert.InDelta(t, float64(c.Wins)/50, c.Frequency, 1e-9)
+	}
+	assert.Equal(t, 50*e.Seats, total, "every recount fills all seats")
+
+	// withdrawn candidates never win
+	assert.Zero(t, got.Candidates[2].Wins)
+	// deterministic winners are flagged
+	assert.True(t, got.Candidates[0].Elected)
+	assert.True(t, got.Candidates[4].Elected)
+}
+
+func TestRun_Reproducible(t *testing.T) {
+	e := readBallots(t, "election12")
+
+	a, err := Run(e, Options{Iterations: 40, Seed: 7, Workers: 1})
+	require.NoError(t, err)
+	b, err := Run(e, Options{Iterations: 40, Seed: 7, Workers: 8})
+	require.NoError(t, err)
+	assert.Equal(t, a, b, "same seed must give the same result regardless of workers")
+}
+
+func TestRun_FractionalWeights(t *testing.T) {
+	e := readBallots(t, "election14")
+	e.Ballots[0].Weight, _ = election.ParseWeight("0.5")
+
+	_, err := Run(e, Options{Iterations: 1})
+	assert.Error(t, err)
+}
+
+func TestResult_WriteCSV(t *testing.T) {
+	e := readBallots(t, "election14")
+	res, err := Run(e, Options{Iterations: 10, Seed: 1})
+	require.NoError(t, err)
+
+	buf := &bytes.Buffer{}
+	require.NoError(t, res.WriteCSV(buf))
+
+	records, err := csv.NewReader(buf).ReadAll()
+	require.NoError(t, err)
+	assert.Len(t, records, e.Candidates+1)
+	assert.Equal(t, []string{"index", "name", "elected", "wins", "frequency"}, records[0])
+}
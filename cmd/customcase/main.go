@@ -42,7 +42,7 @@ func main() {
 
 	add := func(weight int, prefs []int) {
 		// prefs must be zero-based indices into the choices slice above.
-		params.Ballots = append(params.Ballots, election.Ballot{Weight: weight, Preferences: prefs})
+		params.Ballots = append(params.Ballots, election.Ballot{Weight: election.NewWeight(int64(weight)), Preferences: prefs})
 	}
 
 	// Example ballots: each line is weight 1 and a ranked list of candidate indices.
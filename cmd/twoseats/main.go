@@ -11,19 +11,19 @@ import (
 func main() {
 	fmt.Println("== Scenario A: two ballots (one for each choice) ==")
 	runScenario([]election.Ballot{
-		{Weight: 1, Preferences: []int{0}},
-		{Weight: 1, Preferences: []int{1}},
+		{Weight: election.NewWeight(1), Preferences: []int{0}},
+		{Weight: election.NewWeight(1), Preferences: []int{1}},
 	})
 
 	fmt.Println("\n== Scenario B: single ballot ranking both choices ==")
 	runScenario([]election.Ballot{
-		{Weight: 1, Preferences: []int{0, 1}},
+		{Weight: election.NewWeight(1), Preferences: []int{0, 1}},
 	})
 
 	fmt.Println("\n== Scenario C: two ballots both selecting the same choice ==")
 	runScenario([]election.Ballot{
-		{Weight: 1, Preferences: []int{0, 1}},
-		{Weight: 1, Preferences: []int{0, 1}},
+		{Weight: election.NewWeight(1), Preferences: []int{0, 1}},
+		{Weight: election.NewWeight(1), Preferences: []int{0, 1}},
 	})
 }
 
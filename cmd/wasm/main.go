@@ -0,0 +1,59 @@
+//go:build js && wasm
+
+// Command wasm is the WebAssembly build of the counter, for recounting elections in a web browser:
+//
+//	GOOS=js GOARCH=wasm go build -o meekstv.wasm ./cmd/wasm
+//
+// It sets a global meekstv object with the functions parse(ballots), count(ballots, options)
+// and render(ballots, options). ballots is an OpaVote ballot file or an election in the JSON
+// ballot format, and options an object such as {seats: 3, withdrawn: [0], format: "html"}.
+// Each function returns an object holding either the result, or the error.
+package main
+
+import (
+	"syscall/js"
+
+	"github.com/linuxfoundation-it/meek-stv/webapi"
+)
+
+func main() {
+	js.Global().Set("meekstv", js.ValueOf(map[string]any{
+		"parse": js.FuncOf(func(this js.Value, args []js.Value) any {
+			return result(webapi.Parse(arg(args, 0)))
+		}),
+		"count": js.FuncOf(func(this js.Value, args []js.Value) any {
+			return result(webapi.Count(arg(args, 0), options(args)))
+		}),
+		"render": js.FuncOf(func(this js.Value, args []js.Value) any {
+			return result(webapi.Render(arg(args, 0), options(args)))
+		}),
+	}))
+
+	// keep the functions alive
+	select {}
+}
+
+func arg(args []js.Value, i int) string {
+	if i >= len(args) || args[i].Type() != js.TypeString {
+		return ""
+	}
+	return args[i].String()
+}
+
+// options returns the second argument as JSON, whether it is an object or already a string
+func options(args []js.Value) string {
+	if len(args) < 2 || args[1].IsUndefined() || args[1].IsNull() {
+		return ""
+	}
+	if args[1].Type() == js.TypeString {
+		return args[1].String()
+	}
+	return js.Global().Get("JSON").Call("stringify", args[1]).String()
+}
+
+func result(out string, err error) any {
+	if err != nil {
+		return js.ValueOf(map[string]any{"error": err.Error()})
+	}
+	return js.ValueOf(map[string]any{"result": out})
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/linuxfoundation-it/meek-stv/election"
+)
+
+func convertCmd(args []string, env *env) int {
+	fs := newFlagSet("convert", "[ballot file]", "Converts a ballot file between the OpaVote (BLT) and JSON formats.", env)
+	to := fs.String("to", "json", "output format: blt or json")
+	output := fs.String("o", "", "output file (default: standard output)")
+	input := addInputFlags(fs)
+
+	positional, err := parseFlags(fs, args)
+	if err != nil {
+		return usageError(err)
+	}
+	if len(positional) > 1 {
+		fs.Usage()
+		return exitUsage
+	}
+
+	var write func(io.Writer, *election.Election) error
+	switch *to {
+	case "blt":
+		write = election.WriteBLT
+	case "json":
+		write = election.WriteJSON
+	default:
+		fmt.Fprintf(env.stderr, "unknown output format %q\n", *to)
+		return exitUsage
+	}
+
+	e, code, err := input.read(first(positional), env.stderr, env)
+	if err != nil {
+		fmt.Fprintln(env.stderr, err)
+		return code
+	}
+
+	out := env.stdout
+	if *output != "" {
+		f, err := os.Create(*output)
+		if err != nil {
+			fmt.Fprintln(env.stderr, err)
+			return exitFailure
+		}
+		defer f.Close()
+		out = f
+	}
+
+	if err := write(out, e); err != nil {
+		fmt.Fprintln(env.stderr, err)
+		return exitFailure
+	}
+	return exitOK
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/linuxfoundation-it/meek-stv/election"
+	"github.com/linuxfoundation-it/meek-stv/htmlreport"
+	"github.com/linuxfoundation-it/meek-stv/meekstv"
+	"github.com/linuxfoundation-it/meek-stv/opavote"
+	"github.com/linuxfoundation-it/meek-stv/rctab"
+)
+
+func countCmd(args []string, env *env) int {
+	fs := newFlagSet("count", "[ballot file]", "Counts an election and prints the results round by round.", env)
+	cf := addCountFlags(fs)
+	format := fs.String("format", "plain", "output format: plain, table, markdown, html, json, opavote (OpaVote JSON results) or rctab (RCTab summary JSON)")
+	order := fs.String("order", "index", "order of candidates and transfers: index or amount")
+	precision := fs.Int("precision", meekstv.DefaultRenderOptions.Precision, "number of decimals of plain, table, markdown and html output")
+	vacate := fs.String("vacate", "", "comma separated winners leaving their seat, by number (1-based) or name: fills their seats with the other winners locked as elected")
+	winners := fs.String("winners", "", "comma separated winners of the original count, for --vacate; defaults to the winners of counting the ballot file")
+
+	positional, err := parseFlags(fs, args)
+	if err != nil {
+		return usageError(err)
+	}
+	if len(positional) > 1 || *cf.seats < 0 || *precision < 0 {
+		fs.Usage()
+		return exitUsage
+	}
+
+	opts := meekstv.RenderOptions{Precision: *precision}
+	switch *order {
+	case "index":
+		opts.Order = meekstv.ByIndex
+	case "amount":
+		opts.Order = meekstv.ByAmount
+	default:
+		fmt.Fprintf(env.stderr, "unknown order %q\n", *order)
+		return exitUsage
+	}
+	// the formats other than json, html, opavote and rctab are those of the renderers
+	var renderer meekstv.Renderer
+	switch *format {
+	case "json", "html", "opavote", "rctab":
+	default:
+		if renderer, err = meekstv.NewRenderer(*format, opts); err != nil {
+			fmt.Fprintln(env.stderr, err)
+			return exitUsage
+		}
+	}
+
+	e, code := cf.load(first(positional), env)
+	if e == nil {
+		return code
+	}
+
+	report := meekstv.Count(e)
+	if *vacate != "" {
+		if report, err = countback(e, report, *winners, *vacate); err != nil {
+			fmt.Fprintln(env.stderr, err)
+			return exitUsage
+		}
+	}
+
+	switch *format {
+	case "json":
+		err = report.WriteJSON(env.stdout)
+	case "html":
+		err = htmlreport.Write(env.stdout, &report, e, htmlreport.Options{Precision: opts.Precision})
+	case "opavote":
+		err = opavote.FromLog(&report, e, opavote.DefaultPrecision).Write(env.stdout)
+	case "rctab":
+		err = rctab.FromLog(&report, e, rctab.DefaultPrecision).Write(env.stdout)
+	default:
+		err = writeResults(env.stdout, renderer, &report, e, opts.Precision)
+	}
+	if err != nil {
+		fmt.Fprintln(env.stderr, err)
+		return exitFailure
+	}
+	return exitOK
+}
+
+// countback recounts the election to fill the seats of the vacating winners
+func countback(e *election.Election, original meekstv.Log, winnerList, vacateList string) (meekstv.Log, error) {
+	winners := original.Winners()
+	if winnerList != "" {
+		var err error
+		if winners, err = parseCandidates(winnerList, e); err != nil {
+			return meekstv.Log{}, err
+		}
+	}
+	vacating, err := parseCandidates(vacateList, e)
+	if err != nil {
+		return meekstv.Log{}, err
+	}
+	return meekstv.Countback(e, winners, vacating)
+}
+
+// writeResults renders the rounds of the count followed by the winners.
+func writeResults(w io.Writer, renderer meekstv.Renderer, report *meekstv.Log, e *election.Election, precision int) error {
+	elected := make([]meekstv.Candidate, 0)
+	for _, c := range report.Results() {
+		if c.State == meekstv.Elected {
+			elected = append(elected, c)
+		}
+	}
+	sort.SliceStable(elected, func(i, j int) bool {
+		return elected[i].Votes > elected[j].Votes
+	})
+
+	if err := renderer.Render(w, report); err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "Results of %q\n", e.Title)
+	for _, c := range elected {
+		fmt.Fprintf(w, "%q is elected with %.*f votes", c.Name, precision, c.Votes)
+		if c.Class != "" {
+			fmt.Fprintf(w, " to a seat reserved for %s", c.Class)
+		}
+		fmt.Fprintln(w)
+	}
+	if len(elected) < e.Seats {
+		fmt.Fprintf(w, "%d of %d seats are unfilled\n", e.Seats-len(elected), e.Seats)
+	}
+	return nil
+}
+
+func first(args []string) string {
+	if len(args) == 0 {
+		return ""
+	}
+	return args[0]
+}
@@ -0,0 +1,257 @@
+// Package cvr reads ranked ballots from cast vote records in the JSON format of the
+// NIST SP 1500-103 Cast Vote Records Common Data Format.
+package cvr
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/linuxfoundation-it/meek-stv/election"
+)
+
+type Options struct {
+	// Contest is the ID of the contest to read. It can be left empty when the report has a single contest.
+	Contest string
+	// Rules clean the ballots as marked: overvotes, duplicate rankings and skipped ranks
+	Rules election.Rules
+	// IgnoreWriteIns leaves the ranks marking write-ins that aren't resolved to a candidate blank.
+	// By default every such write-in selection of the contest is a candidate of its own.
+	IgnoreWriteIns bool
+}
+
+// report is the part of a CVR.CastVoteRecordReport read here
+type report struct {
+	Election []struct {
+		ID        string `json:"@id"`
+		Name      string
+		Candidate []struct {
+			ID   string `json:"@id"`
+			Name string
+		}
+		Contest []contest
+	}
+	CVR []record
+}
+
+type contest struct {
+	ID               string `json:"@id"`
+	Name             string
+	NumberElected    int
+	ContestSelection []struct {
+		ID           string `json:"@id"`
+		CandidateIds []string
+		IsWriteIn    bool
+	}
+}
+
+type record struct {
+	ID                string `json:"@id"`
+	UniqueId          string
+	BallotAuditId     string
+	CurrentSnapshotId string
+	CVRSnapshot       []struct {
+		ID         string `json:"@id"`
+		CVRContest []struct {
+			ContestId           string
+			CVRContestSelection []struct {
+				ContestSelectionId string
+				Rank               int
+				SelectionPosition  []struct {
+					HasIndication string
+					Rank          int
+				}
+			}
+		}
+	}
+}
+
+// id returns the ID of the record: its unique ID, its ballot audit ID, or its JSON ID
+func (r *record) id() string {
+	for _, id := range []string{r.UniqueId, r.BallotAuditId, r.ID} {
+		if id != "" {
+			return id
+		}
+	}
+	return ""
+}
+
+// Read reads the ballots of a contest from a cast vote record report, one per record, with the ID of
+// the record, and returns them as an election along with what cleaning them changed.
+//
+// The candidates are the selections of the contest, in order, and its number of seats the number elected,
+// 1 by default. A selection position ranks its candidate unless it has no indication. Its rank is that of
+// the position, or else that of the selection. Overvotes are the marks of several candidates at a rank,
+// whether or not the records tell them allocable. Records without the contest are left out.
+func Read(r io.Reader, opts Options) (*election.Election, election.CleanReport, error) {
+	var clean election.CleanReport
+	var doc report
+	if err := json.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, clean, err
+	}
+
+	names := make(map[string]string)
+	var contests []contest
+	title := ""
+	for _, e := range doc.Election {
+		for _, c := range e.Candidate {
+			names[c.ID] = c.Name
+		}
+		for _, c := range e.Contest {
+			if opts.Contest == "" || c.ID == opts.Contest {
+				contests = append(contests, c)
+				title = e.Name
+			}
+		}
+	}
+	switch {
+	case len(contests) == 0 && opts.Contest != "":
+		return nil, clean, fmt.Errorf("no contest %q", opts.Contest)
+	case len(contests) == 0:
+		return nil, clean, fmt.Errorf("no contest")
+	case len(contests) > 1:
+		ids := make([]string, len(contests))
+		for i, c := range contests {
+			ids[i] = c.ID
+		}
+		return nil, clean, fmt.Errorf("several contests, choose one of %s", strings.Join(ids, ", "))
+	}
+	contest := contests[0]
+
+	e := &election.Election{Title: contest.Name, Seats: max(contest.NumberElected, 1)}
+	if e.Title == "" {
+		e.Title = title
+	}
+	candidate, err := candidates(contest, names, opts.IgnoreWriteIns, e)
+	if err != nil {
+		return nil, clean, err
+	}
+
+	for i := range doc.CVR {
+		rec := &doc.CVR[i]
+		ranks, ok, err := marks(rec, contest.ID, candidate)
+		if err != nil {
+			return nil, clean, fmt.Errorf("cast vote record %q: %w", rec.id(), err)
+		}
+		if !ok {
+			continue
+		}
+		m := election.MarkedBallot{ID: rec.id(), Weight: election.NewWeight(1), Ranks: ranks}
+		if b, ok := opts.Rules.Clean(m, &clean); ok {
+			e.Ballots = append(e.Ballots, b)
+		}
+	}
+	return e, clean, nil
+}
+
+// candidates adds the candidates of the selections of contest to e, and returns the candidate
+// of each selection, by ID. Ignored write-ins have candidate -1.
+func candidates(contest contest, names map[string]string, ignoreWriteIns bool, e *election.Election) (map[string]int, error) {
+	out := make(map[string]int, len(contest.ContestSelection))
+	index := make(map[string]int)
+	writeIns := 0
+	for _, s := range contest.ContestSelection {
+		if s.IsWriteIn && len(s.CandidateIds) == 0 {
+			writeIns++
+		}
+	}
+
+	writeIn := 0
+	for _, s := range contest.ContestSelection {
+		if _, ok := out[s.ID]; ok {
+			return nil, fmt.Errorf("contest selection %q is listed twice", s.ID)
+		}
+		var key, name string
+		switch {
+		case len(s.CandidateIds) > 0:
+			// a selection of several candidates, such as a ticket, is a single candidate
+			ids := append([]string(nil), s.CandidateIds...)
+			sort.Strings(ids)
+			key = strings.Join(ids, "\x00")
+			parts := make([]string, len(s.CandidateIds))
+			for i, id := range s.CandidateIds {
+				n, ok := names[id]
+				if !ok {
+					return nil, fmt.Errorf("contest selection %q: unknown candidate %q", s.ID, id)
+				}
+				parts[i] = n
+			}
+			name = strings.Join(parts, " / ")
+		case s.IsWriteIn:
+			if ignoreWriteIns {
+				out[s.ID] = -1
+				continue
+			}
+			writeIn++
+			key, name = "\x00"+s.ID, "Write-in"
+			if writeIns > 1 {
+				name = fmt.Sprintf("Write-in %d", writeIn)
+			}
+		default:
+			return nil, fmt.Errorf("contest selection %q has no candidate", s.ID)
+		}
+
+		// selections of the same candidates, such as a resolved write-in, are the same candidate
+		c, ok := index[key]
+		if !ok {
+			c = e.Candidates
+			index[key] = c
+			e.Candidates++
+			e.CandidateNames = append(e.CandidateNames, name)
+		}
+		out[s.ID] = c
+	}
+	return out, nil
+}
+
+// marks returns the candidates rec marks at each rank of the contest, and whether it includes the contest
+func marks(rec *record, contest string, candidate map[string]int) ([][]int, bool, error) {
+	if len(rec.CVRSnapshot) == 0 {
+		return nil, false, nil
+	}
+	snapshot := &rec.CVRSnapshot[len(rec.CVRSnapshot)-1]
+	for i := range rec.CVRSnapshot {
+		if rec.CVRSnapshot[i].ID == rec.CurrentSnapshotId {
+			snapshot = &rec.CVRSnapshot[i]
+		}
+	}
+
+	var ranks [][]int
+	found := false
+	for _, c := range snapshot.CVRContest {
+		if c.ContestId != contest {
+			continue
+		}
+		found = true
+		for _, s := range c.CVRContestSelection {
+			for _, p := range s.SelectionPosition {
+				if strings.EqualFold(p.HasIndication, "no") {
+					continue
+				}
+				rank := p.Rank
+				if rank == 0 {
+					rank = s.Rank
+				}
+				if rank < 1 {
+					return nil, false, fmt.Errorf("contest selection %q has no rank", s.ContestSelectionId)
+				}
+				if rank > len(candidate) {
+					return nil, false, fmt.Errorf("rank %d beyond the %d selections of the contest", rank, len(candidate))
+				}
+				cand, ok := candidate[s.ContestSelectionId]
+				if !ok {
+					return nil, false, fmt.Errorf("unknown contest selection %q", s.ContestSelectionId)
+				}
+				for len(ranks) < rank {
+					ranks = append(ranks, nil)
+				}
+				if cand >= 0 {
+					ranks[rank-1] = append(ranks[rank-1], cand)
+				}
+			}
+		}
+	}
+	return ranks, found, nil
+}
@@ -0,0 +1,124 @@
+package cvr
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/linuxfoundation-it/meek-stv/election"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func read(t *testing.T, opts Options) (*election.Election, election.CleanReport) {
+	t.Helper()
+	f, err := os.Open("../testdata/cvr/springfield.json")
+	require.NoError(t, err)
+	defer f.Close()
+	e, report, err := Read(f, opts)
+	require.NoError(t, err)
+	return e, report
+}
+
+// ballots returns the ballots of e by ID
+func ballots(e *election.Election) map[string]election.Ballot {
+	out := make(map[string]election.Ballot)
+	for _, b := range e.Ballots {
+		out[b.ID] = b
+	}
+	return out
+}
+
+func TestRead(t *testing.T) {
+	e, report := read(t, Options{Contest: "contest-mayor"})
+	assert.Equal(t, "Mayor", e.Title)
+	assert.Equal(t, 1, e.Seats)
+	assert.Equal(t, []string{"Alice Adams", "Bob Brown", "Carol Clark", "Dave Davis", "Write-in"}, e.CandidateNames)
+	assert.Equal(t, 5, e.Candidates)
+	assert.False(t, report.Changed())
+
+	// every record of the contest is a ballot, in order, under its ID
+	require.Len(t, e.Ballots, 10)
+	assert.Equal(t, "cvr-001", e.Ballots[0].ID)
+	assert.Equal(t, "cvr-011", e.Ballots[9].ID)
+	for _, b := range e.Ballots {
+		assert.Equal(t, election.NewWeight(1), b.Weight)
+	}
+
+	got := ballots(e)
+	assert.Equal(t, []int{0, 1, 2}, got["cvr-001"].Preferences)
+	assert.Equal(t, []int{2, 4}, got["cvr-003"].Preferences)
+	assert.Equal(t, [][]int{{0}, {1, 2}, {3}}, got["cvr-004"].Groups())
+	assert.Equal(t, []int{3, 1}, got["cvr-005"].Preferences)
+	assert.Equal(t, []int{0, 0, 3}, got["cvr-006"].Preferences)
+	assert.Equal(t, []int{4, 2}, got["cvr-007"].Preferences)
+	assert.Empty(t, got["cvr-008"].Preferences)
+	assert.Equal(t, []int{1}, got["cvr-009"].Preferences)
+	assert.NotContains(t, got, "cvr-010")
+	// the current snapshot has the write-in adjudicated
+	assert.Equal(t, []int{2, 0}, got["cvr-011"].Preferences)
+}
+
+func TestRead_Rules(t *testing.T) {
+	rules := election.Rules{Overvotes: election.Exhaust, Duplicates: election.Skip, SkippedRanks: election.Reject}
+	e, report := read(t, Options{Contest: "contest-mayor", Rules: rules})
+	one := election.NewWeight(1)
+	assert.Equal(t, election.CleanReport{Overvotes: one, Duplicates: one, SkippedRanks: one, Rejected: one}, report)
+
+	got := ballots(e)
+	assert.Equal(t, []int{0}, got["cvr-004"].Preferences)
+	assert.Equal(t, []int{0, 3}, got["cvr-006"].Preferences)
+	assert.NotContains(t, got, "cvr-005")
+}
+
+func TestRead_IgnoreWriteIns(t *testing.T) {
+	e, report := read(t, Options{Contest: "contest-mayor", IgnoreWriteIns: true, Rules: election.Rules{SkippedRanks: election.Skip}})
+	assert.Equal(t, []string{"Alice Adams", "Bob Brown", "Carol Clark", "Dave Davis"}, e.CandidateNames)
+
+	got := ballots(e)
+	assert.Equal(t, []int{2}, got["cvr-003"].Preferences)
+	// the write-in rank is left blank, and skipped
+	assert.Equal(t, []int{2}, got["cvr-007"].Preferences)
+	assert.Equal(t, election.NewWeight(2), report.SkippedRanks)
+}
+
+func TestRead_Contest(t *testing.T) {
+	e, _ := read(t, Options{Contest: "contest-council"})
+	assert.Equal(t, "City Council", e.Title)
+	assert.Equal(t, 2, e.Seats)
+	require.Len(t, e.Ballots, 2)
+	assert.Equal(t, "cvr-010", e.Ballots[1].ID)
+
+	f, err := os.Open("../testdata/cvr/springfield.json")
+	require.NoError(t, err)
+	defer f.Close()
+	_, _, err = Read(f, Options{})
+	assert.ErrorContains(t, err, "contest-mayor, contest-council")
+}
+
+func TestRead_Invalid(t *testing.T) {
+	contest := `"Election": [{"Candidate": [{"@id": "a", "Name": "A"}], "Contest": [{"@id": "c", "ContestSelection": [{"@id": "s", "CandidateIds": ["a"]}]}]}]`
+	record := func(selection, rank string) string {
+		return `"CVR": [{"UniqueId": "r", "CVRSnapshot": [{"CVRContest": [{"ContestId": "c", "CVRContestSelection": [
+			{"ContestSelectionId": "` + selection + `", "SelectionPosition": [{"HasIndication": "yes", "Rank": ` + rank + `}]}]}]}]}]`
+	}
+	for name, doc := range map[string]string{
+		"not json":          `{`,
+		"no contest":        `{"Election": []}`,
+		"unknown contest":   `{` + contest + `}`,
+		"unknown candidate": `{"Election": [{"Contest": [{"@id": "c", "ContestSelection": [{"@id": "s", "CandidateIds": ["x"]}]}]}]}`,
+		"no candidate":      `{"Election": [{"Contest": [{"@id": "c", "ContestSelection": [{"@id": "s"}]}]}]}`,
+		"unknown selection": `{` + contest + `, ` + record("x", "1") + `}`,
+		"no rank":           `{` + contest + `, ` + record("s", "0") + `}`,
+		"rank too high":     `{` + contest + `, ` + record("s", "2") + `}`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			opts := Options{Contest: "c"}
+			if name == "unknown contest" {
+				opts.Contest = "d"
+			}
+			_, _, err := Read(strings.NewReader(doc), opts)
+			assert.Error(t, err)
+		})
+	}
+}
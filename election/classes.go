@@ -0,0 +1,56 @@
+package election
+
+import (
+	"errors"
+	"fmt"
+)
+
+// SeatClass reserves seats for the candidates of a class, such as a region or a membership class.
+// The seats of the election that no class reserves are open to every candidate.
+type SeatClass struct {
+	Name  string
+	Seats int
+	// Candidates are the candidates eligible for the reserved seats, by index
+	Candidates []int
+}
+
+// OpenSeats returns the number of seats reserved for no class.
+func (e *Election) OpenSeats() int {
+	open := e.Seats
+	for _, class := range e.Classes {
+		open -= class.Seats
+	}
+	return open
+}
+
+// ValidateClasses checks that the seat classes are named, reserve at least one seat,
+// no more seats than the election has, and that no candidate belongs to two classes.
+func (e *Election) ValidateClasses() error {
+	names := make(map[string]bool, len(e.Classes))
+	classOf := make(map[int]string)
+	for _, class := range e.Classes {
+		if class.Name == "" {
+			return errors.New("seat class without a name")
+		}
+		if names[class.Name] {
+			return fmt.Errorf("seat class %q is defined twice", class.Name)
+		}
+		names[class.Name] = true
+		if class.Seats < 1 {
+			return fmt.Errorf("seat class %q reserves %d seats", class.Name, class.Seats)
+		}
+		for _, c := range class.Candidates {
+			if c < 0 || c >= e.Candidates {
+				return fmt.Errorf("seat class %q: candidate %d out of range", class.Name, c)
+			}
+			if other, ok := classOf[c]; ok {
+				return fmt.Errorf("candidate %d is in seat classes %q and %q", c, other, class.Name)
+			}
+			classOf[c] = class.Name
+		}
+	}
+	if open := e.OpenSeats(); open < 0 {
+		return fmt.Errorf("seat classes reserve %d seats more than the %d of the election", -open, e.Seats)
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+package election
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJSON_Classes(t *testing.T) {
+	want := readTestdata(t, "election13")
+	want.Classes = []SeatClass{{Name: "APAC", Seats: 1, Candidates: []int{1, 4}}}
+
+	buf := &bytes.Buffer{}
+	require.NoError(t, WriteJSON(buf, want))
+	assert.Contains(t, buf.String(), `"name": "APAC"`)
+
+	got, err := ReadJSON(buf)
+	require.NoError(t, err)
+	assert.Equal(t, want, got)
+	assert.Equal(t, 1, got.OpenSeats())
+}
+
+func TestValidateClasses(t *testing.T) {
+	tests := map[string][]SeatClass{
+		"no name":        {{Seats: 1, Candidates: []int{0}}},
+		"no seats":       {{Name: "A", Candidates: []int{0}}},
+		"duplicate name": {{Name: "A", Seats: 1}, {Name: "A", Seats: 1}},
+		"out of range":   {{Name: "A", Seats: 1, Candidates: []int{3}}},
+		"two classes":    {{Name: "A", Seats: 1, Candidates: []int{0}}, {Name: "B", Seats: 1, Candidates: []int{0}}},
+		"too many seats": {{Name: "A", Seats: 2, Candidates: []int{0}}, {Name: "B", Seats: 1, Candidates: []int{1}}},
+	}
+	for name, classes := range tests {
+		t.Run(name, func(t *testing.T) {
+			e := &Election{Candidates: 3, Seats: 2, Classes: classes}
+			assert.Error(t, e.ValidateClasses())
+		})
+	}
+
+	e := &Election{Candidates: 3, Seats: 2, Withdrawn: map[int]bool{1: true},
+		Classes: []SeatClass{{Name: "A", Seats: 2, Candidates: []int{0, 1}}}}
+	require.NoError(t, e.ValidateClasses())
+	assert.Equal(t, []string{`seat class "A" reserves 2 seats for 1 candidates`}, e.Warnings())
+}
+
+func TestOverride(t *testing.T) {
+	e := &Election{Candidates: 3, Seats: 2, Classes: []SeatClass{{Name: "A", Seats: 2, Candidates: []int{0, 1}}}}
+	require.NoError(t, e.Override(0, []int{2}))
+	assert.Equal(t, 2, e.Seats)
+	assert.Equal(t, map[int]bool{2: true}, e.Withdrawn)
+
+	require.NoError(t, e.Override(3, nil))
+	assert.Equal(t, 3, e.Seats)
+
+	assert.ErrorContains(t, e.Override(-1, nil), "negative")
+	assert.ErrorContains(t, e.Override(0, []int{3}), "out of range")
+	assert.ErrorContains(t, e.Override(1, nil), "seats more than")
+}
@@ -0,0 +1,196 @@
+package election
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Policy is what cleaning does with a ballot at the first rank that breaks a rule.
+type Policy int
+
+const (
+	// Keep leaves the ballot as marked: candidates marked at the same rank are ranked equally,
+	// and candidates marked twice are counted at each of their ranks. Skipped ranks are closed up.
+	Keep Policy = iota
+	// Skip ignores the rank and reads the ballot on from the next one.
+	// A duplicate ranking is ignored, and the other candidates of its rank kept.
+	Skip
+	// Exhaust ignores the rank and the rest of the ballot, keeping the ranks before it.
+	Exhaust
+	// Reject drops the whole ballot.
+	Reject
+)
+
+var policyNames = []string{"keep", "skip", "exhaust", "reject"}
+
+// ParsePolicy parses the name of a policy: keep, skip, exhaust or reject, in any case.
+func ParsePolicy(s string) (Policy, error) {
+	for p, name := range policyNames {
+		if strings.EqualFold(s, name) {
+			return Policy(p), nil
+		}
+	}
+	return Keep, fmt.Errorf("unknown policy %q, want one of %s", s, strings.Join(policyNames, ", "))
+}
+
+func (p Policy) String() string {
+	if p < 0 || int(p) >= len(policyNames) {
+		return fmt.Sprintf("Policy(%d)", int(p))
+	}
+	return policyNames[p]
+}
+
+// Rules are the policies of ballot cleaning. The zero value keeps ballots as they are.
+//
+// The major RCV standards set them as follows, among others: Maine exhausts ballots at an overvote and
+// after two consecutive skipped ranks (SkippedRanks Exhaust, MaxSkipped 1), and skips duplicate rankings;
+// the Universal RCV Tabulator configures the same three rules; OpaVote skips overvotes and skipped ranks.
+type Rules struct {
+	// Overvotes applies to ranks where more than one candidate is marked.
+	Overvotes Policy
+	// Duplicates applies to ranks marking a candidate already marked at a higher rank, or twice.
+	Duplicates Policy
+	// SkippedRanks applies to ranks left blank before a rank with marks, once more than
+	// MaxSkipped consecutive ranks are blank. Blank ranks at the end of a ballot are never skipped ranks.
+	SkippedRanks Policy
+	MaxSkipped   int
+}
+
+// MarkedBallot is a ballot as marked by the voter, before cleaning.
+type MarkedBallot struct {
+	ID     string
+	Weight Weight
+	// Ranks holds the candidates marked at each rank, from the highest: none where
+	// the rank is skipped, and more than one where it is overvoted
+	Ranks [][]int
+}
+
+// Marks returns b as marked, candidates ranked equally sharing a rank.
+func (b Ballot) Marks() MarkedBallot {
+	return MarkedBallot{ID: b.ID, Weight: b.Weight, Ranks: b.Groups()}
+}
+
+// CleanReport counts the ballots each rule changed, multiplied by their weight.
+// A ballot breaking several rules is counted under each of those applied to it.
+type CleanReport struct {
+	Overvotes    Weight `json:"overvotes"`
+	Duplicates   Weight `json:"duplicates"`
+	SkippedRanks Weight `json:"skipped_ranks"`
+	// Rejected counts the ballots dropped by a Reject policy, whatever the rule
+	Rejected Weight `json:"rejected"`
+}
+
+// Changed tells whether cleaning changed any ballot.
+func (r CleanReport) Changed() bool {
+	return r.Overvotes.Sign() > 0 || r.Duplicates.Sign() > 0 || r.SkippedRanks.Sign() > 0 || r.Rejected.Sign() > 0
+}
+
+// Clean returns the ballot m is cleaned into, adding the rules it breaks to report.
+// It returns false if the ballot is rejected.
+func (r Rules) Clean(m MarkedBallot, report *CleanReport) (Ballot, bool) {
+	b := Ballot{ID: m.ID, Weight: m.Weight, Preferences: make([]int, 0, len(m.Ranks))}
+	var overvoted, duplicated, skipped bool
+	seen := make(map[int]bool, len(m.Ranks))
+	blank := 0
+
+	// apply returns whether to read the ballot on, and whether to keep it
+	apply := func(p Policy, broke *bool) (bool, bool) {
+		if p == Keep {
+			return true, true
+		}
+		*broke = true
+		return p == Skip, p != Reject
+	}
+
+	ok := true
+ranks:
+	for _, rank := range m.Ranks {
+		if len(rank) == 0 {
+			blank++
+			continue
+		}
+		if blank > 0 && blank > r.MaxSkipped {
+			next, keep := apply(r.SkippedRanks, &skipped)
+			if ok = keep; !next {
+				break
+			}
+		}
+		blank = 0
+
+		marks := rank
+		for i, c := range rank {
+			if !seen[c] && !contains(rank[:i], c) {
+				continue
+			}
+			next, keep := apply(r.Duplicates, &duplicated)
+			if ok = keep; !next {
+				break ranks
+			}
+			if r.Duplicates == Skip {
+				marks = unique(rank, seen)
+			}
+			break
+		}
+		if len(marks) == 0 {
+			continue
+		}
+
+		if len(marks) > 1 {
+			next, keep := apply(r.Overvotes, &overvoted)
+			if ok = keep; !next {
+				break
+			}
+			if r.Overvotes == Skip {
+				continue
+			}
+		}
+
+		for i, c := range marks {
+			b.addPreference(c, i > 0)
+			seen[c] = true
+		}
+	}
+
+	for _, rule := range []struct {
+		broke bool
+		count *Weight
+	}{{overvoted, &report.Overvotes}, {duplicated, &report.Duplicates}, {skipped, &report.SkippedRanks}, {!ok, &report.Rejected}} {
+		if rule.broke {
+			*rule.count = rule.count.Add(m.Weight)
+		}
+	}
+	return b, ok
+}
+
+// Apply replaces the ballots of e with their cleaned ballots, dropping the rejected ones, and returns what it changed.
+func (r Rules) Apply(e *Election) CleanReport {
+	var report CleanReport
+	kept := make([]Ballot, 0, len(e.Ballots))
+	for _, b := range e.Ballots {
+		if cleaned, ok := r.Clean(b.Marks(), &report); ok {
+			kept = append(kept, cleaned)
+		}
+	}
+	e.Ballots = kept
+	return report
+}
+
+func contains(s []int, c int) bool {
+	for _, x := range s {
+		if x == c {
+			return true
+		}
+	}
+	return false
+}
+
+// unique returns the candidates of rank that aren't in seen, nor repeated in rank
+func unique(rank []int, seen map[int]bool) []int {
+	out := make([]int, 0, len(rank))
+	for _, c := range rank {
+		if !seen[c] && !contains(out, c) {
+			out = append(out, c)
+		}
+	}
+	return out
+}
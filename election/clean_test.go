@@ -0,0 +1,89 @@
+package election
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRules_Clean(t *testing.T) {
+	w := NewWeight(2)
+	tests := []struct {
+		name   string
+		rules  Rules
+		ranks  [][]int
+		want   []int
+		equal  []bool
+		report CleanReport
+		reject bool
+	}{
+		{"strict", Rules{Overvotes: Reject, Duplicates: Reject, SkippedRanks: Reject}, [][]int{{0}, {1}, {2}}, []int{0, 1, 2}, nil, CleanReport{}, false},
+		{"keep overvote", Rules{}, [][]int{{0}, {1, 2}}, []int{0, 1, 2}, []bool{false, false, true}, CleanReport{}, false},
+		{"skip overvote", Rules{Overvotes: Skip}, [][]int{{0}, {1, 2}, {3}}, []int{0, 3}, nil, CleanReport{Overvotes: w}, false},
+		{"exhaust overvote", Rules{Overvotes: Exhaust}, [][]int{{0}, {1, 2}, {3}}, []int{0}, nil, CleanReport{Overvotes: w}, false},
+		{"reject overvote", Rules{Overvotes: Reject}, [][]int{{0}, {1, 2}}, nil, nil, CleanReport{Overvotes: w, Rejected: w}, true},
+		{"keep duplicate", Rules{}, [][]int{{0}, {1}, {0}}, []int{0, 1, 0}, nil, CleanReport{}, false},
+		{"skip duplicate", Rules{Duplicates: Skip}, [][]int{{0}, {0}, {1}}, []int{0, 1}, nil, CleanReport{Duplicates: w}, false},
+		{"skip duplicate in overvote", Rules{Duplicates: Skip, Overvotes: Exhaust}, [][]int{{0}, {0, 1}, {2}}, []int{0, 1, 2}, nil, CleanReport{Duplicates: w}, false},
+		{"exhaust duplicate", Rules{Duplicates: Exhaust}, [][]int{{0}, {1}, {0}, {2}}, []int{0, 1}, nil, CleanReport{Duplicates: w}, false},
+		{"duplicate at the same rank", Rules{Duplicates: Reject}, [][]int{{1, 1}}, nil, nil, CleanReport{Duplicates: w, Rejected: w}, true},
+		{"keep skipped rank", Rules{}, [][]int{{0}, {}, {1}}, []int{0, 1}, nil, CleanReport{}, false},
+		{"skip skipped rank", Rules{SkippedRanks: Skip}, [][]int{{}, {0}, {}, {}, {1}}, []int{0, 1}, nil, CleanReport{SkippedRanks: w}, false},
+		{"exhaust skipped rank", Rules{SkippedRanks: Exhaust}, [][]int{{0}, {}, {1}}, []int{0}, nil, CleanReport{SkippedRanks: w}, false},
+		{"one skipped rank allowed", Rules{SkippedRanks: Exhaust, MaxSkipped: 1}, [][]int{{0}, {}, {1}, {}, {}, {2}}, []int{0, 1}, nil, CleanReport{SkippedRanks: w}, false},
+		{"trailing blank ranks", Rules{SkippedRanks: Reject}, [][]int{{0}, {}, {}}, []int{0}, nil, CleanReport{}, false},
+		{"blank", Rules{SkippedRanks: Reject}, [][]int{{}, {}}, []int{}, nil, CleanReport{}, false},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var report CleanReport
+			got, ok := tc.rules.Clean(MarkedBallot{Weight: w, Ranks: tc.ranks}, &report)
+			assert.Equal(t, !tc.reject, ok)
+			assert.Equal(t, tc.report, report)
+			if !tc.reject {
+				assert.Equal(t, Ballot{Weight: w, Preferences: tc.want, Equal: tc.equal}, got)
+			}
+		})
+	}
+}
+
+func TestRules_Apply(t *testing.T) {
+	e := &Election{
+		Candidates: 3,
+		Seats:      1,
+		Ballots: []Ballot{
+			{Weight: NewWeight(3), Preferences: []int{0, 1, 2}, Equal: []bool{false, true, false}},
+			{Weight: NewWeight(2), Preferences: []int{2, 2}},
+			{Weight: NewWeight(1), Preferences: []int{1, 0}},
+		},
+	}
+
+	// the zero rules change nothing
+	want := append([]Ballot(nil), e.Ballots...)
+	report := Rules{}.Apply(e)
+	assert.False(t, report.Changed())
+	assert.Equal(t, want, e.Ballots)
+
+	report = Rules{Overvotes: Reject, Duplicates: Skip}.Apply(e)
+	assert.True(t, report.Changed())
+	assert.Equal(t, CleanReport{Overvotes: NewWeight(3), Rejected: NewWeight(3), Duplicates: NewWeight(2)}, report)
+	assert.Equal(t, []Ballot{
+		{Weight: NewWeight(2), Preferences: []int{2}},
+		{Weight: NewWeight(1), Preferences: []int{1, 0}},
+	}, e.Ballots)
+}
+
+func TestParsePolicy(t *testing.T) {
+	for _, p := range []Policy{Keep, Skip, Exhaust, Reject} {
+		got, err := ParsePolicy(p.String())
+		require.NoError(t, err)
+		assert.Equal(t, p, got)
+	}
+	got, err := ParsePolicy("Skip")
+	require.NoError(t, err)
+	assert.Equal(t, Skip, got)
+
+	_, err = ParsePolicy("ignore")
+	assert.Error(t, err)
+}
@@ -1,101 +1,112 @@
 package election
 
 import (
-	"bufio"
+	"fmt"
 	"io"
 	"strconv"
 	"strings"
 )
 
+// Read reads an OpaVote ballot file and closes in. It panics if the file is malformed,
+// use Parse to handle errors.
 func Read(in io.ReadCloser) *Election {
-	s := bufio.NewScanner(in)
 	defer in.Close()
 
-	election := &Election{}
-
-scanner:
-	for i := 0; s.Scan(); i++ {
-		t := s.Text()
-
-		switch i {
-		case 0:
-			election.Candidates, election.Seats = parseHeader(t)
-
-		case 1:
-			if strings.HasPrefix(t, "-") {
-				election.Withdrawn = parseWithdrawn(t)
-				continue
-			}
-			fallthrough
-
-		default:
-			if t == "0" {
-				break scanner
-			}
-			election.Ballots = append(election.Ballots, parseBallot(t))
-		}
+	election, err := Parse(in)
+	if err != nil {
+		panic(err)
 	}
+	return election
+}
 
-	names := make([]string, 0)
-	for s.Scan() {
-		names = append(names, s.Text())
+// Parse reads an election from an OpaVote ballot file (BLT format), with a ballot per line.
+// Lines can be of any length; see Stream to read ballots one at a time, and ParseAggregated
+// to merge identical ones.
+func Parse(in io.Reader) (*Election, error) {
+	s, err := NewStream(in)
+	if err != nil {
+		return nil, err
 	}
-	election.CandidateNames = names[:len(names)-1]
-	election.Title = names[len(names)-1]
-
-	return election
+	var ballots []Ballot
+	for b := range s.Ballots() {
+		ballots = append(ballots, b.Clone())
+	}
+	election, err := s.Election()
+	if err != nil {
+		return nil, err
+	}
+	election.Ballots = ballots
+	return election, nil
 }
 
-func parseHeader(line string) (candidates, seats int) {
-	ss := strings.Split(line, " ")
-	is := asInts(ss)
+func parseHeader(line string) (candidates, seats int, err error) {
+	is, err := asInts(strings.Fields(line))
+	if err != nil {
+		return 0, 0, err
+	}
+	if len(is) != 2 {
+		return 0, 0, fmt.Errorf("header must contain the number of candidates and seats, got %q", line)
+	}
 	candidates, seats = is[0], is[1]
-	return
+	if candidates < 0 || seats < 0 {
+		return 0, 0, fmt.Errorf("negative number of candidates or seats in %q", line)
+	}
+	return candidates, seats, nil
 }
 
-func parseWithdrawn(line string) map[int]bool {
-	ss := strings.Split(line, " ")
-	is := asInts(ss)
+func parseWithdrawn(line string, candidates int) (map[int]bool, error) {
+	is, err := asInts(strings.Fields(line))
+	if err != nil {
+		return nil, err
+	}
 
 	out := make(map[int]bool)
 	for _, i := range is {
+		if i >= 0 || i < -candidates {
+			return nil, fmt.Errorf("invalid withdrawn candidate %d", i)
+		}
 		// substract 1 to make it 0-indexed
 		out[i*(-1)-1] = true
 	}
-	return out
+	return out, nil
 }
 
-func parseBallot(line string) Ballot {
-	k := strings.Split(line, " ")
-	return Ballot{
-		Weight:      asInt(k[0]),
-		Preferences: normalize(asInts(k[1 : len(k)-1])),
+// addPreference appends candidate c to the preferences of b, ranked equal to the previous one if equal is true
+func (b *Ballot) addPreference(c int, equal bool) {
+	if equal && b.Equal == nil {
+		b.Equal = make([]bool, len(b.Preferences), cap(b.Preferences))
+	}
+	b.Preferences = append(b.Preferences, c)
+	if b.Equal != nil {
+		b.Equal = append(b.Equal, equal)
 	}
 }
 
-func asInt(s string) int {
-	i, err := strconv.Atoi(s)
-	if err != nil {
-		panic(err)
+// Unquote strips the double quotes around the candidate names and the title, which ballot files
+// write them with, and which Parse keeps. Names without quotes are left as they are.
+func (e *Election) Unquote() {
+	for i, name := range e.CandidateNames {
+		e.CandidateNames[i] = unquote(name)
 	}
-	return i
+	e.Title = unquote(e.Title)
 }
 
-func asInts(ss []string) (out []int) {
-	for _, s := range ss {
-		n := asInt(strings.TrimSpace(s))
-		if n == 0 {
-			continue
-		}
-		out = append(out, n)
+// strip the double quotes around candidate names and title
+func unquote(s string) string {
+	if len(s) >= 2 && strings.HasPrefix(s, `"`) && strings.HasSuffix(s, `"`) {
+		return s[1 : len(s)-1]
 	}
-	return
+	return s
 }
 
-// subtract 1 to all candidate indices to make it 0-based
-func normalize(ns []int) []int {
-	for i := range ns {
-		ns[i] = ns[i] - 1
+func asInts(ss []string) ([]int, error) {
+	out := make([]int, 0, len(ss))
+	for _, s := range ss {
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %q", s)
+		}
+		out = append(out, n)
 	}
-	return ns
+	return out, nil
 }
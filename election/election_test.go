@@ -0,0 +1,98 @@
+package election
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func readTestdata(t *testing.T, name string) *Election {
+	t.Helper()
+	f, err := os.Open("../testdata/" + name + ".txt")
+	require.NoError(t, err)
+	return Read(f)
+}
+
+func TestRead(t *testing.T) {
+	e := readTestdata(t, "election13")
+
+	assert.Equal(t, 6, e.Candidates)
+	assert.Equal(t, 2, e.Seats)
+	assert.Equal(t, map[int]bool{2: true}, e.Withdrawn)
+	assert.Equal(t, `"Zoe"`, e.CandidateNames[0])
+	assert.Equal(t, `"Stack Overflow Moderator Election 2021"`, e.Title)
+	assert.Equal(t, Ballot{Weight: NewWeight(20), Preferences: []int{0, 1, 2, 3, 4, 5}}, e.Ballots[0])
+}
+
+func TestParse_Names(t *testing.T) {
+	e, err := Parse(bytes.NewBufferString("3 1\n1 1 0\n0\n\"A\"\nB\n  \"Say \"C\"\"  \n\"Title\"\n"))
+	require.NoError(t, err)
+	// names are read as they are, and written back without more quotes
+	assert.Equal(t, []string{`"A"`, "B", `"Say "C""`}, e.CandidateNames)
+	buf := &bytes.Buffer{}
+	require.NoError(t, WriteBLT(buf, e))
+	assert.Contains(t, buf.String(), "\n\"A\"\n\"B\"\n\"Say \"C\"\"\n\"Title\"\n")
+
+	// Unquote drops the quotes around names, those within them are kept
+	e.Unquote()
+	assert.Equal(t, []string{"A", "B", `Say "C"`}, e.CandidateNames)
+	assert.Equal(t, "Title", e.Title)
+
+	buf.Reset()
+	require.NoError(t, WriteBLT(buf, e))
+	assert.Contains(t, buf.String(), "\n\"A\"\n\"B\"\n\"Say \"C\"\"\n\"Title\"\n")
+}
+
+func TestWriteBLT(t *testing.T) {
+	for _, name := range []string{"election12", "election14"} {
+		want := readTestdata(t, name)
+
+		buf := &bytes.Buffer{}
+		require.NoError(t, WriteBLT(buf, want))
+
+		got := Read(io.NopCloser(buf))
+		assert.Equal(t, want, got)
+	}
+}
+
+func TestJSON(t *testing.T) {
+	want := readTestdata(t, "election13")
+
+	buf := &bytes.Buffer{}
+	require.NoError(t, WriteJSON(buf, want))
+
+	got, err := ReadJSON(buf)
+	require.NoError(t, err)
+	assert.Equal(t, want, got)
+}
+
+func TestReadJSON_OutOfRange(t *testing.T) {
+	_, err := ReadJSON(bytes.NewBufferString(`{"seats": 1, "candidates": ["a", "b"], "ballots": [{"weight": 1, "preferences": [2]}]}`))
+	assert.Error(t, err)
+}
+
+func TestParse_Malformed(t *testing.T) {
+	tests := map[string]string{
+		"empty":               "",
+		"bad header":          "6\n0\n",
+		"negative seats":      "2 -1\n0\n\"A\"\n\"B\"\n\"T\"\n",
+		"withdrawn range":     "2 1\n-3\n0\n\"A\"\n\"B\"\n\"T\"\n",
+		"preference range":    "2 1\n1 3 0\n0\n\"A\"\n\"B\"\n\"T\"\n",
+		"negative weight":     "2 1\n-1 1 0\n0\n\"A\"\n\"B\"\n\"T\"\n",
+		"unterminated ballot": "2 1\n1 1 2\n0\n\"A\"\n\"B\"\n\"T\"\n",
+		"missing end marker":  "2 1\n1 1 2 0\n",
+		"missing names":       "2 1\n1 1 2 0\n0\n\"A\"\n",
+		"not a number":        "2 1\n1 a 0\n0\n\"A\"\n\"B\"\n\"T\"\n",
+		"withdrawn overflow":  "2 1\n-9223372036854775808\n0\n\"A\"\n\"B\"\n\"T\"\n",
+	}
+	for name, input := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := Parse(bytes.NewBufferString(input))
+			assert.Error(t, err)
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package election
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const equalBLT = "4 1\n3 1 2=3 4 0\n2 4=1 0\n1 3 0\n0\n\"A\"\n\"B\"\n\"C\"\n\"D\"\n\"Equal\"\n"
+
+func TestParse_Equal(t *testing.T) {
+	e, err := Parse(bytes.NewBufferString(equalBLT))
+	require.NoError(t, err)
+
+	assert.Equal(t, Ballot{Weight: NewWeight(3), Preferences: []int{0, 1, 2, 3}, Equal: []bool{false, false, true, false}}, e.Ballots[0])
+	assert.Equal(t, [][]int{{0}, {1, 2}, {3}}, e.Ballots[0].Groups())
+	assert.Equal(t, [][]int{{3, 0}}, e.Ballots[1].Groups())
+	assert.False(t, e.Ballots[0].Strict())
+
+	// strict ballots are left as they were
+	assert.Nil(t, e.Ballots[2].Equal)
+	assert.True(t, e.Ballots[2].Strict())
+}
+
+func TestWriteBLT_Equal(t *testing.T) {
+	want, err := Parse(bytes.NewBufferString(equalBLT))
+	require.NoError(t, err)
+
+	buf := &bytes.Buffer{}
+	require.NoError(t, WriteBLT(buf, want))
+	assert.Contains(t, buf.String(), "3 1 2=3 4 0\n")
+
+	got := Read(io.NopCloser(buf))
+	assert.Equal(t, want, got)
+}
+
+func TestJSON_Equal(t *testing.T) {
+	want, err := Parse(bytes.NewBufferString(equalBLT))
+	require.NoError(t, err)
+
+	buf := &bytes.Buffer{}
+	require.NoError(t, WriteJSON(buf, want))
+	assert.Contains(t, buf.String(), `"preferences": [
+        0,
+        [
+          1,
+          2
+        ],
+        3
+      ]`)
+
+	got, err := ReadJSON(buf)
+	require.NoError(t, err)
+	assert.Equal(t, want.Ballots, got.Ballots)
+}
+
+func TestParse_MalformedEqual(t *testing.T) {
+	for name, input := range map[string]string{
+		"trailing equal": "2 1\n1 1= 0\n0\n\"A\"\n\"B\"\n\"T\"\n",
+		"leading equal":  "2 1\n1 =1 2 0\n0\n\"A\"\n\"B\"\n\"T\"\n",
+		"equal to end":   "2 1\n1 1=0\n0\n\"A\"\n\"B\"\n\"T\"\n",
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := Parse(bytes.NewBufferString(input))
+			assert.Error(t, err)
+		})
+	}
+
+	for name, input := range map[string]string{
+		"empty group": `[]`,
+		"nested":      `[[0]]`,
+		"not a rank":  `"a"`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := ReadJSON(bytes.NewBufferString(`{"seats": 1, "candidates": ["a", "b"], "ballots": [{"weight": 1, "preferences": [0, ` + input + `]}]}`))
+			assert.Error(t, err)
+		})
+	}
+}
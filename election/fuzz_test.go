@@ -0,0 +1,50 @@
+package election
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// addCorpus seeds f with every ballot file in testdata
+func addCorpus(f *testing.F) {
+	files, err := filepath.Glob("../testdata/*.txt")
+	if err != nil {
+		f.Fatal(err)
+	}
+	for _, name := range files {
+		data, err := os.ReadFile(name)
+		if err != nil {
+			f.Fatal(err)
+		}
+		f.Add(data)
+	}
+	f.Add([]byte("3 1\n-2\n1 1 2 0\n2 0\n0\n\"A\"\n\"B\"\n\"C\"\n\"Title\"\n"))
+	f.Add([]byte("2 1\n1 3 0\n0\n"))
+	f.Add([]byte(""))
+}
+
+func FuzzParse(f *testing.F) {
+	addCorpus(f)
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		e, err := Parse(bytes.NewReader(data))
+		if err != nil {
+			return
+		}
+		if len(e.CandidateNames) != e.Candidates {
+			t.Fatalf("parsed %d names for %d candidates", len(e.CandidateNames), e.Candidates)
+		}
+		for i, b := range e.Ballots {
+			if b.Weight.Sign() < 0 {
+				t.Fatalf("ballot %d has negative weight %s", i, b.Weight)
+			}
+			for _, p := range b.Preferences {
+				if p < 0 || p >= e.Candidates {
+					t.Fatalf("ballot %d ranks candidate %d out of range", i, p)
+				}
+			}
+		}
+	})
+}
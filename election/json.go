@@ -0,0 +1,147 @@
+package election
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// jsonElection is the JSON ballot format. Unlike the OpaVote ballot file,
+// candidate indices in withdrawn and preferences are zero-based. A preference
+// is a candidate, or an array of candidates ranked equally. Ballots may carry an id.
+type jsonElection struct {
+	Title      string       `json:"title"`
+	Seats      int          `json:"seats"`
+	Candidates []string     `json:"candidates"`
+	Withdrawn  []int        `json:"withdrawn,omitempty"`
+	Classes    []jsonClass  `json:"classes,omitempty"`
+	Ballots    []jsonBallot `json:"ballots"`
+}
+
+// jsonClass is a seat class, reserving seats for the candidates listed by index
+type jsonClass struct {
+	Name       string `json:"name"`
+	Seats      int    `json:"seats"`
+	Candidates []int  `json:"candidates"`
+}
+
+type jsonBallot struct {
+	ID          string     `json:"id,omitempty"`
+	Weight      Weight     `json:"weight"`
+	Preferences []jsonRank `json:"preferences"`
+}
+
+// jsonRank is a preference: a candidate, or an array of candidates ranked equally
+type jsonRank []int
+
+func (r jsonRank) MarshalJSON() ([]byte, error) {
+	if len(r) == 1 {
+		return json.Marshal(r[0])
+	}
+	return json.Marshal([]int(r))
+}
+
+func (r *jsonRank) UnmarshalJSON(data []byte) error {
+	if len(data) > 0 && data[0] == '[' {
+		var group []int
+		if err := json.Unmarshal(data, &group); err != nil {
+			return err
+		}
+		if len(group) == 0 {
+			return errors.New("empty group of equally ranked candidates")
+		}
+		*r = group
+		return nil
+	}
+	var c int
+	if err := json.Unmarshal(data, &c); err != nil {
+		return err
+	}
+	*r = jsonRank{c}
+	return nil
+}
+
+// ReadJSON reads an election in the JSON ballot format.
+func ReadJSON(r io.Reader) (*Election, error) {
+	var doc jsonElection
+	if err := json.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, err
+	}
+
+	if doc.Seats < 0 {
+		return nil, fmt.Errorf("negative number of seats %d", doc.Seats)
+	}
+
+	e := &Election{
+		Title:          doc.Title,
+		Candidates:     len(doc.Candidates),
+		Seats:          doc.Seats,
+		Withdrawn:      make(map[int]bool),
+		Ballots:        make([]Ballot, 0, len(doc.Ballots)),
+		CandidateNames: doc.Candidates,
+	}
+	for _, c := range doc.Withdrawn {
+		if c < 0 || c >= e.Candidates {
+			return nil, fmt.Errorf("withdrawn candidate %d out of range", c)
+		}
+		e.Withdrawn[c] = true
+	}
+	for _, class := range doc.Classes {
+		e.Classes = append(e.Classes, SeatClass{Name: class.Name, Seats: class.Seats, Candidates: class.Candidates})
+	}
+	if err := e.ValidateClasses(); err != nil {
+		return nil, err
+	}
+	for i, b := range doc.Ballots {
+		if b.Weight.Sign() < 0 {
+			return nil, fmt.Errorf("ballot %d: negative weight %s", i, b.Weight)
+		}
+		ballot := Ballot{ID: b.ID, Weight: b.Weight, Preferences: make([]int, 0, len(b.Preferences))}
+		for _, rank := range b.Preferences {
+			for k, p := range rank {
+				if p < 0 || p >= e.Candidates {
+					return nil, fmt.Errorf("ballot %d: candidate %d out of range", i, p)
+				}
+				ballot.addPreference(p, k > 0)
+			}
+		}
+		e.Ballots = append(e.Ballots, ballot)
+	}
+	return e, nil
+}
+
+// WriteJSON writes e in the JSON ballot format.
+func WriteJSON(w io.Writer, e *Election) error {
+	doc := jsonElection{
+		Title:      e.Title,
+		Seats:      e.Seats,
+		Candidates: e.CandidateNames,
+		Ballots:    make([]jsonBallot, len(e.Ballots)),
+	}
+	for i, ok := range e.Withdrawn {
+		if ok {
+			doc.Withdrawn = append(doc.Withdrawn, i)
+		}
+	}
+	sort.Ints(doc.Withdrawn)
+	for _, class := range e.Classes {
+		candidates := class.Candidates
+		if candidates == nil {
+			candidates = []int{}
+		}
+		doc.Classes = append(doc.Classes, jsonClass{Name: class.Name, Seats: class.Seats, Candidates: candidates})
+	}
+	for i, b := range e.Ballots {
+		prefs := make([]jsonRank, 0, len(b.Preferences))
+		for _, group := range b.Groups() {
+			prefs = append(prefs, group)
+		}
+		doc.Ballots[i] = jsonBallot{ID: b.ID, Weight: b.Weight, Preferences: prefs}
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(doc)
+}
@@ -1,5 +1,7 @@
 package election
 
+import "fmt"
+
 type Election struct {
 	Title          string
 	Candidates     int
@@ -7,27 +9,98 @@ type Election struct {
 	Withdrawn      map[int]bool
 	Ballots        []Ballot
 	CandidateNames []string
+	// Classes reserve some of the seats for some of the candidates
+	Classes []SeatClass
 }
 
-func (e *Election) CountEmpty() int {
-	n := 0
+// CountEmpty returns the weight of the ballots that rank no candidate, or only withdrawn ones.
+func (e *Election) CountEmpty() Weight {
+	var n Weight
 	for _, b := range e.Ballots {
 		if b.IsEmpty() || b.AllWithdrawn(e.Withdrawn) {
-			n += b.Weight
+			n = n.Add(b.Weight)
 		}
 	}
 	return n
 }
 
+// TotalWeight returns the weight of all the ballots.
+func (e *Election) TotalWeight() Weight {
+	var n Weight
+	for _, b := range e.Ballots {
+		n = n.Add(b.Weight)
+	}
+	return n
+}
+
+// Override sets the number of seats of e to seats when it is positive and withdraws the candidates
+// of withdrawn, by index, as the count options of the commands and APIs do. It checks the seat classes
+// against the new number of seats.
+func (e *Election) Override(seats int, withdrawn []int) error {
+	if seats < 0 {
+		return fmt.Errorf("negative number of seats %d", seats)
+	}
+	if seats > 0 {
+		e.Seats = seats
+	}
+	if len(withdrawn) > 0 && e.Withdrawn == nil {
+		e.Withdrawn = make(map[int]bool)
+	}
+	for _, c := range withdrawn {
+		if c < 0 || c >= e.Candidates {
+			return fmt.Errorf("withdrawn candidate %d out of range", c)
+		}
+		e.Withdrawn[c] = true
+	}
+	return e.ValidateClasses()
+}
+
 type Ballot struct {
-	Weight      int
+	// ID identifies the ballot for audits, such as the ID of its cast vote record.
+	// It is empty for ballot files that don't identify ballots.
+	ID          string
+	Weight      Weight
 	Preferences []int // indices
+	// Equal marks equal rankings: Equal[k] ranks Preferences[k] equal to Preferences[k-1].
+	// It is nil on ballots that rank candidates strictly.
+	Equal []bool
 }
 
 func (b Ballot) IsEmpty() bool {
 	return len(b.Preferences) == 0
 }
 
+// GroupEnd returns the end of the group of equally ranked candidates that starts at Preferences[k],
+// that is the index of the first preference ranked below it.
+func (b Ballot) GroupEnd(k int) int {
+	end := k + 1
+	for end < len(b.Equal) && b.Equal[end] {
+		end++
+	}
+	return end
+}
+
+// Groups returns the preferences of b as groups of equally ranked candidates, from the highest rank.
+func (b Ballot) Groups() [][]int {
+	var out [][]int
+	for k := 0; k < len(b.Preferences); {
+		end := b.GroupEnd(k)
+		out = append(out, b.Preferences[k:end])
+		k = end
+	}
+	return out
+}
+
+// Strict tells whether b ranks no candidates equally.
+func (b Ballot) Strict() bool {
+	for _, eq := range b.Equal {
+		if eq {
+			return false
+		}
+	}
+	return true
+}
+
 func (b Ballot) AllWithdrawn(withdrawn map[int]bool) bool {
 	a := false
 	for _, candidate := range b.Preferences {
@@ -38,3 +111,45 @@ func (b Ballot) AllWithdrawn(withdrawn map[int]bool) bool {
 	}
 	return a
 }
+
+// Warnings returns the suspicious, but valid, features of an election: more seats
+// than candidates, ballots ranking a candidate twice, ballots only ranking withdrawn candidates,
+// and seat classes with fewer candidates than reserved seats.
+func (e *Election) Warnings() []string {
+	var out []string
+	if e.Seats > e.Candidates {
+		out = append(out, fmt.Sprintf("%d seats for %d candidates", e.Seats, e.Candidates))
+	}
+	duplicates, withdrawn := 0, 0
+	for _, b := range e.Ballots {
+		seen := make(map[int]bool)
+		for _, p := range b.Preferences {
+			if seen[p] {
+				duplicates++
+				break
+			}
+			seen[p] = true
+		}
+		if b.AllWithdrawn(e.Withdrawn) {
+			withdrawn++
+		}
+	}
+	if duplicates > 0 {
+		out = append(out, fmt.Sprintf("%d ballot lines rank a candidate more than once", duplicates))
+	}
+	if withdrawn > 0 {
+		out = append(out, fmt.Sprintf("%d ballot lines only rank withdrawn candidates", withdrawn))
+	}
+	for _, class := range e.Classes {
+		eligible := 0
+		for _, c := range class.Candidates {
+			if !e.Withdrawn[c] {
+				eligible++
+			}
+		}
+		if eligible < class.Seats {
+			out = append(out, fmt.Sprintf("seat class %q reserves %d seats for %d candidates", class.Name, class.Seats, eligible))
+		}
+	}
+	return out
+}
@@ -0,0 +1,357 @@
+package election
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+)
+
+// Stream reads an OpaVote ballot file (BLT format) one ballot at a time, holding a single line
+// in memory whatever the length of the file or of its lines.
+//
+// The header is read by NewStream, the ballots by iterating over Ballots, and the candidate names
+// and title that follow them by Election.
+type Stream struct {
+	r    *bufio.Reader
+	buf  []byte
+	line int
+	// pending is a ballot line read by NewStream, while looking for withdrawn candidates
+	pending []byte
+
+	candidates, seats int
+	withdrawn         map[int]bool
+
+	ballot Ballot
+	done   bool
+	err    error
+}
+
+// NewStream reads the header of a ballot file: the number of candidates and seats, and the withdrawn candidates.
+func NewStream(in io.Reader) (*Stream, error) {
+	s := &Stream{r: bufio.NewReader(in)}
+	line, ok := s.readLine()
+	if !ok {
+		if s.err != nil {
+			return nil, s.err
+		}
+		return nil, errors.New("missing header")
+	}
+	var err error
+	if s.candidates, s.seats, err = parseHeader(string(line)); err != nil {
+		return nil, fmt.Errorf("line 1: %w", err)
+	}
+
+	line, ok = s.readLine()
+	switch {
+	case !ok && s.err != nil:
+		return nil, s.err
+	case ok && bytes.HasPrefix(line, []byte("-")):
+		if s.withdrawn, err = parseWithdrawn(string(line), s.candidates); err != nil {
+			return nil, fmt.Errorf("line 2: %w", err)
+		}
+	case ok:
+		s.pending = line
+	}
+	return s, nil
+}
+
+// Candidates returns the number of candidates.
+func (s *Stream) Candidates() int {
+	return s.candidates
+}
+
+// Seats returns the number of seats.
+func (s *Stream) Seats() int {
+	return s.seats
+}
+
+// Withdrawn returns the withdrawn candidates.
+func (s *Stream) Withdrawn() map[int]bool {
+	return s.withdrawn
+}
+
+// Ballots returns an iterator over the ballots that are left to read. The slices of each ballot
+// are reused for the next one: keep them past an iteration with Clone, or in a BallotStore.
+// The iteration stops at the end of ballots marker, or at the first error, returned by Err.
+func (s *Stream) Ballots() iter.Seq[Ballot] {
+	return func(yield func(Ballot) bool) {
+		for !s.done && s.err == nil {
+			line, ok := s.pending, s.pending != nil
+			s.pending = nil
+			if !ok {
+				if line, ok = s.readLine(); !ok {
+					if s.err == nil {
+						s.err = errors.New("missing end of ballots marker")
+					}
+					return
+				}
+			}
+			if string(line) == "0" {
+				s.done = true
+				return
+			}
+			if err := parseBallotLine(line, s.candidates, &s.ballot); err != nil {
+				s.err = fmt.Errorf("line %d: %w", s.line, err)
+				return
+			}
+			if !yield(s.ballot) {
+				return
+			}
+		}
+	}
+}
+
+// Err returns the error that stopped reading the ballots, if any.
+func (s *Stream) Err() error {
+	return s.err
+}
+
+// Election reads the candidate names and title that follow the ballots, skipping the ballots left to read,
+// and returns the election without its ballots.
+func (s *Stream) Election() (*Election, error) {
+	for range s.Ballots() {
+	}
+	if s.err != nil {
+		return nil, s.err
+	}
+
+	names := make([]string, 0)
+	for len(names) <= s.candidates {
+		line, ok := s.readLine()
+		if !ok {
+			break
+		}
+		if len(line) > 0 {
+			names = append(names, string(line))
+		}
+	}
+	if s.err != nil {
+		return nil, s.err
+	}
+	if len(names) <= s.candidates {
+		return nil, fmt.Errorf("expected %d candidate names and a title, found %d lines", s.candidates, len(names))
+	}
+	return &Election{
+		Title:          names[len(names)-1],
+		Candidates:     s.candidates,
+		Seats:          s.seats,
+		Withdrawn:      s.withdrawn,
+		CandidateNames: names[:len(names)-1],
+	}, nil
+}
+
+// readLine reads the next line, of any length, trimmed of spaces. It returns false at the end
+// of the input, or on errors, kept in s.err.
+func (s *Stream) readLine() ([]byte, bool) {
+	s.buf = s.buf[:0]
+	for {
+		chunk, err := s.r.ReadSlice('\n')
+		s.buf = append(s.buf, chunk...)
+		if errors.Is(err, bufio.ErrBufferFull) {
+			continue
+		}
+		if errors.Is(err, io.EOF) && len(s.buf) == 0 {
+			return nil, false
+		}
+		if err != nil && !errors.Is(err, io.EOF) {
+			s.err = err
+			return nil, false
+		}
+		s.line++
+		return bytes.TrimSpace(s.buf), true
+	}
+}
+
+// parseBallotLine parses a ballot line into b, reusing its slices: its weight, then its preferences,
+// where candidates ranked equally are joined by "=", as in "2=3", and a final 0.
+func parseBallotLine(line []byte, candidates int, b *Ballot) error {
+	i := bytes.LastIndexAny(line, spaces)
+	if i < 0 || string(line[i+1:]) != "0" {
+		return fmt.Errorf("ballot %q must start with a weight and end with 0", truncate(line))
+	}
+	weight, rest := nextField(line[:i])
+	var err error
+	if b.Weight, err = parseWeightBytes(weight); err != nil {
+		return err
+	}
+	b.Preferences, b.Equal = b.Preferences[:0], nil
+	for {
+		var rank []byte
+		if rank, rest = nextField(rest); len(rank) == 0 {
+			return nil
+		}
+		for i := 0; ; i++ {
+			n, after, found := bytes.Cut(rank, []byte("="))
+			p, err := atoi(n)
+			if err != nil {
+				return err
+			}
+			if p < 1 || p > candidates {
+				return fmt.Errorf("candidate %d out of range", p)
+			}
+			b.addPreference(p-1, i > 0)
+			if !found {
+				break
+			}
+			rank = after
+		}
+	}
+}
+
+// spaces separate the fields of ballot lines
+const spaces = " \t\r\v\f"
+
+// nextField returns the first field of the space separated line, and the rest of the line after it
+func nextField(line []byte) (field, rest []byte) {
+	line = bytes.TrimLeft(line, spaces)
+	if i := bytes.IndexAny(line, spaces); i >= 0 {
+		return line[:i], line[i:]
+	}
+	return line, nil
+}
+
+// parseWeightBytes parses a weight like ParseWeight, without allocating for small integers
+func parseWeightBytes(s []byte) (Weight, error) {
+	if len(s) > 0 && len(s) <= 18 {
+		n := int64(0)
+		for _, c := range s {
+			if c < '0' || c > '9' {
+				return ParseWeight(string(s))
+			}
+			n = n*10 + int64(c-'0')
+		}
+		return NewWeight(n), nil
+	}
+	return ParseWeight(string(s))
+}
+
+// atoi parses a decimal number of at most 18 digits, with an optional sign
+func atoi(s []byte) (int, error) {
+	digits := bytes.TrimPrefix(s, []byte("-"))
+	neg := len(digits) < len(s)
+	if len(digits) == 0 || len(digits) > 18 {
+		return 0, fmt.Errorf("invalid number %q", s)
+	}
+	n := 0
+	for _, c := range digits {
+		if c < '0' || c > '9' {
+			return 0, fmt.Errorf("invalid number %q", s)
+		}
+		n = n*10 + int(c-'0')
+	}
+	if neg {
+		n = -n
+	}
+	return n, nil
+}
+
+// truncate shortens long lines quoted in errors
+func truncate(line []byte) string {
+	if len(line) > 80 {
+		return string(line[:80]) + "..."
+	}
+	return string(line)
+}
+
+// Clone returns a copy of b that shares no slices with it.
+func (b Ballot) Clone() Ballot {
+	b.Preferences = append(make([]int, 0, len(b.Preferences)), b.Preferences...)
+	if b.Equal != nil {
+		b.Equal = append(make([]bool, 0, len(b.Equal)), b.Equal...)
+	}
+	return b
+}
+
+// BallotStore aggregates identical ballots into one, of their total weight, in order of first appearance.
+// Ballots are identical when they rank the same candidates in the same way; their IDs are dropped.
+// The preferences of all the ballots are kept back to back, so that each distinct ballot takes little more
+// memory than its preferences.
+type BallotStore struct {
+	index   map[string]int
+	prefs   []int
+	equal   []bool
+	ballots []storedBallot
+	key     []byte
+	total   Weight
+}
+
+type storedBallot struct {
+	start, end int
+	strict     bool
+	weight     Weight
+}
+
+// NewBallotStore returns an empty store.
+func NewBallotStore() *BallotStore {
+	return &BallotStore{index: make(map[string]int)}
+}
+
+// Add adds b to the store, which keeps no reference to its slices.
+func (st *BallotStore) Add(b Ballot) {
+	st.total = st.total.Add(b.Weight)
+	st.key = st.key[:0]
+	for k, p := range b.Preferences {
+		v := uint64(p) << 1
+		if k < len(b.Equal) && b.Equal[k] {
+			v |= 1
+		}
+		st.key = binary.AppendUvarint(st.key, v)
+	}
+	if i, ok := st.index[string(st.key)]; ok {
+		st.ballots[i].weight = st.ballots[i].weight.Add(b.Weight)
+		return
+	}
+
+	st.index[string(st.key)] = len(st.ballots)
+	start := len(st.prefs)
+	st.prefs = append(st.prefs, b.Preferences...)
+	for k := range b.Preferences {
+		st.equal = append(st.equal, k < len(b.Equal) && b.Equal[k])
+	}
+	st.ballots = append(st.ballots, storedBallot{start: start, end: len(st.prefs), strict: b.Strict(), weight: b.Weight})
+}
+
+// Len returns the number of distinct ballots.
+func (st *BallotStore) Len() int {
+	return len(st.ballots)
+}
+
+// TotalWeight returns the weight of all the ballots added.
+func (st *BallotStore) TotalWeight() Weight {
+	return st.total
+}
+
+// Ballots returns the distinct ballots, whose preferences share the memory of the store.
+func (st *BallotStore) Ballots() []Ballot {
+	out := make([]Ballot, len(st.ballots))
+	for i, sb := range st.ballots {
+		out[i] = Ballot{Weight: sb.weight, Preferences: st.prefs[sb.start:sb.end:sb.end]}
+		if !sb.strict {
+			out[i].Equal = st.equal[sb.start:sb.end:sb.end]
+		}
+	}
+	return out
+}
+
+// ParseAggregated reads an election from an OpaVote ballot file like Parse, streaming its ballots
+// into a BallotStore, so that its memory grows with the number of distinct ballots rather than of lines.
+func ParseAggregated(in io.Reader) (*Election, error) {
+	s, err := NewStream(in)
+	if err != nil {
+		return nil, err
+	}
+	st := NewBallotStore()
+	for b := range s.Ballots() {
+		st.Add(b)
+	}
+	e, err := s.Election()
+	if err != nil {
+		return nil, err
+	}
+	e.Ballots = st.Ballots()
+	return e, nil
+}
@@ -0,0 +1,209 @@
+package election
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStream(t *testing.T) {
+	s, err := NewStream(strings.NewReader("3 2\n-2\n2 1 3 0\n1 3=1 0\n0\n\"A\"\n\"B\"\n\"C\"\n\"Title\"\n"))
+	require.NoError(t, err)
+	assert.Equal(t, 3, s.Candidates())
+	assert.Equal(t, 2, s.Seats())
+	assert.Equal(t, map[int]bool{1: true}, s.Withdrawn())
+
+	var got []Ballot
+	for b := range s.Ballots() {
+		got = append(got, b.Clone())
+	}
+	require.NoError(t, s.Err())
+	assert.Equal(t, []Ballot{
+		{Weight: NewWeight(2), Preferences: []int{0, 2}},
+		{Weight: NewWeight(1), Preferences: []int{2, 0}, Equal: []bool{false, true}},
+	}, got)
+
+	e, err := s.Election()
+	require.NoError(t, err)
+	assert.Equal(t, `"Title"`, e.Title)
+	assert.Equal(t, []string{`"A"`, `"B"`, `"C"`}, e.CandidateNames)
+	assert.Empty(t, e.Ballots)
+}
+
+func TestStream_Break(t *testing.T) {
+	s, err := NewStream(strings.NewReader("2 1\n1 1 0\n2 2 0\n3 1 2 0\n0\n\"A\"\n\"B\"\n\"Title\"\n"))
+	require.NoError(t, err)
+	for b := range s.Ballots() {
+		assert.Equal(t, NewWeight(1), b.Weight)
+		break
+	}
+	// the ballots left are skipped
+	e, err := s.Election()
+	require.NoError(t, err)
+	assert.Equal(t, `"Title"`, e.Title)
+}
+
+func TestStream_Error(t *testing.T) {
+	s, err := NewStream(strings.NewReader("2 1\n1 1 0\n1 3 0\n0\n\"A\"\n\"B\"\n\"Title\"\n"))
+	require.NoError(t, err)
+	n := 0
+	for range s.Ballots() {
+		n++
+	}
+	assert.Equal(t, 1, n)
+	assert.ErrorContains(t, s.Err(), "line 3: candidate 3 out of range")
+	_, err = s.Election()
+	assert.Error(t, err)
+}
+
+func TestParse_LongLine(t *testing.T) {
+	// a ballot line longer than the 64KB limit of bufio.Scanner
+	const candidates = 20000
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%d 1\n1", candidates)
+	for c := 1; c <= candidates; c++ {
+		fmt.Fprintf(&sb, " %d", c)
+	}
+	sb.WriteString(" 0\n0\n")
+	for c := 1; c <= candidates; c++ {
+		fmt.Fprintf(&sb, "\"C%d\"\n", c)
+	}
+	sb.WriteString("\"Long\"\n")
+
+	e, err := Parse(strings.NewReader(sb.String()))
+	require.NoError(t, err)
+	require.Len(t, e.Ballots, 1)
+	assert.Len(t, e.Ballots[0].Preferences, candidates)
+	assert.Equal(t, candidates-1, e.Ballots[0].Preferences[candidates-1])
+	assert.Equal(t, `"Long"`, e.Title)
+}
+
+func TestParseAggregated(t *testing.T) {
+	in := "3 1\n1 1 2 0\n2 3 0\n1 1 2 0\n0.5 1=2 0\n1 1 2 3 0\n1 0\n1.5 1 2 0\n2 0\n0\n\"A\"\n\"B\"\n\"C\"\n\"Title\"\n"
+	e, err := ParseAggregated(strings.NewReader(in))
+	require.NoError(t, err)
+	assert.Equal(t, []Ballot{
+		{Weight: mustWeight(t, "3.5"), Preferences: []int{0, 1}},
+		{Weight: NewWeight(2), Preferences: []int{2}},
+		{Weight: mustWeight(t, "0.5"), Preferences: []int{0, 1}, Equal: []bool{false, true}},
+		{Weight: NewWeight(1), Preferences: []int{0, 1, 2}},
+		{Weight: NewWeight(3), Preferences: []int{}},
+	}, e.Ballots)
+
+	full, err := Parse(strings.NewReader(in))
+	require.NoError(t, err)
+	assert.Equal(t, full.TotalWeight(), e.TotalWeight())
+	assert.Equal(t, full.CountEmpty(), e.CountEmpty())
+	assert.Equal(t, full.CandidateNames, e.CandidateNames)
+}
+
+func TestParseAggregated_Testdata(t *testing.T) {
+	files, err := filepath.Glob("../testdata/*.txt")
+	require.NoError(t, err)
+	for _, path := range files {
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		full, err := Parse(bytes.NewReader(data))
+		require.NoError(t, err)
+		e, err := ParseAggregated(bytes.NewReader(data))
+		require.NoError(t, err)
+		assert.Equal(t, full.TotalWeight(), e.TotalWeight(), path)
+		assert.LessOrEqual(t, len(e.Ballots), len(full.Ballots), path)
+	}
+}
+
+func mustWeight(t *testing.T, s string) Weight {
+	t.Helper()
+	w, err := ParseWeight(s)
+	require.NoError(t, err)
+	return w
+}
+
+// writeBallotFile writes a ballot file of n ballots ranking some of 10 candidates at random
+func writeBallotFile(b *testing.B, n int) string {
+	b.Helper()
+	path := filepath.Join(b.TempDir(), "ballots.blt")
+	f, err := os.Create(path)
+	require.NoError(b, err)
+	defer f.Close()
+	w := bufio.NewWriter(f)
+	rng := rand.New(rand.NewPCG(1, 0))
+	fmt.Fprintln(w, "10 3")
+	for i := 0; i < n; i++ {
+		w.WriteString("1")
+		for _, c := range rng.Perm(10)[:1+rng.IntN(3)] {
+			fmt.Fprintf(w, " %d", c+1)
+		}
+		w.WriteString(" 0\n")
+	}
+	w.WriteString("0\n")
+	for c := 1; c <= 10; c++ {
+		fmt.Fprintf(w, "\"C%d\"\n", c)
+	}
+	w.WriteString("\"Benchmark\"\n")
+	require.NoError(b, w.Flush())
+	return path
+}
+
+// benchmarkRead reads a generated file of 2 million ballots with read, reporting the heap
+// memory still in use afterwards
+func benchmarkRead(b *testing.B, read func(*os.File) any) {
+	path := writeBallotFile(b, 2_000_000)
+	b.ReportAllocs()
+	b.ResetTimer()
+	var retained uint64
+	for i := 0; i < b.N; i++ {
+		f, err := os.Open(path)
+		require.NoError(b, err)
+		var before, after runtime.MemStats
+		runtime.GC()
+		runtime.ReadMemStats(&before)
+		v := read(f)
+		runtime.GC()
+		runtime.ReadMemStats(&after)
+		runtime.KeepAlive(v)
+		f.Close()
+		if after.HeapAlloc > before.HeapAlloc {
+			retained = after.HeapAlloc - before.HeapAlloc
+		}
+	}
+	b.ReportMetric(float64(retained)/(1<<20), "retained-MB")
+}
+
+func BenchmarkParse(b *testing.B) {
+	benchmarkRead(b, func(f *os.File) any {
+		e, err := Parse(f)
+		require.NoError(b, err)
+		return e
+	})
+}
+
+func BenchmarkParseAggregated(b *testing.B) {
+	benchmarkRead(b, func(f *os.File) any {
+		e, err := ParseAggregated(f)
+		require.NoError(b, err)
+		return e
+	})
+}
+
+func BenchmarkStream(b *testing.B) {
+	benchmarkRead(b, func(f *os.File) any {
+		s, err := NewStream(f)
+		require.NoError(b, err)
+		var total Weight
+		for ballot := range s.Ballots() {
+			total = total.Add(ballot.Weight)
+		}
+		require.NoError(b, s.Err())
+		return total
+	})
+}
@@ -0,0 +1,188 @@
+package election
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// Weight is the number of votes a ballot line stands for, as an exact decimal number:
+// integers for counts of identical ballots, fractions such as 0.5 for weighted voting.
+// The zero value is a weight of 0.
+type Weight struct {
+	// n holds weights that are integers fitting an int64, r all others,
+	// so that equal weights have the same representation
+	n int64
+	r *big.Rat
+}
+
+// NewWeight returns the weight of n votes.
+func NewWeight(n int64) Weight {
+	return Weight{n: n}
+}
+
+// ParseWeight parses a non-negative decimal number, such as 3, 0.25 or 1.5e6.
+func ParseWeight(s string) (Weight, error) {
+	if strings.HasPrefix(s, "-") {
+		return Weight{}, fmt.Errorf("negative weight %s", s)
+	}
+	if !isDecimal(s) {
+		return Weight{}, fmt.Errorf("invalid weight %q", s)
+	}
+	if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return Weight{n: n}, nil
+	}
+	r, ok := new(big.Rat).SetString(s)
+	if !ok {
+		return Weight{}, fmt.Errorf("invalid weight %q", s)
+	}
+	return newWeight(r), nil
+}
+
+// isDecimal tells whether s is made of digits, with an optional fractional part
+// and an exponent of at most two digits
+func isDecimal(s string) bool {
+	mantissa, exponent, hasExponent := strings.Cut(strings.ToLower(s), "e")
+	whole, fraction, hasFraction := strings.Cut(mantissa, ".")
+	if whole == "" && fraction == "" || !digits(whole) || !digits(fraction) || hasFraction && fraction == "" {
+		return false
+	}
+	if hasExponent {
+		exponent = strings.TrimLeft(exponent, "+-")
+		return exponent != "" && len(exponent) <= 2 && digits(exponent)
+	}
+	return true
+}
+
+func digits(s string) bool {
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func newWeight(r *big.Rat) Weight {
+	if r.IsInt() && r.Num().IsInt64() {
+		return Weight{n: r.Num().Int64()}
+	}
+	return Weight{r: r}
+}
+
+func (w Weight) rat() *big.Rat {
+	if w.r != nil {
+		return w.r
+	}
+	return new(big.Rat).SetInt64(w.n)
+}
+
+// Add returns w + o.
+func (w Weight) Add(o Weight) Weight {
+	if w.r == nil && o.r == nil {
+		if sum := w.n + o.n; (sum > w.n) == (o.n > 0) {
+			return Weight{n: sum}
+		}
+	}
+	return newWeight(new(big.Rat).Add(w.rat(), o.rat()))
+}
+
+// Cmp returns -1, 0 or +1 depending on whether w is less than, equal to, or greater than o.
+func (w Weight) Cmp(o Weight) int {
+	if w.r == nil && o.r == nil {
+		switch {
+		case w.n < o.n:
+			return -1
+		case w.n > o.n:
+			return 1
+		}
+		return 0
+	}
+	return w.rat().Cmp(o.rat())
+}
+
+// Sign returns -1, 0 or +1 depending on the sign of w.
+func (w Weight) Sign() int {
+	return w.Cmp(Weight{})
+}
+
+// IsInt tells whether w is an integer number of votes.
+func (w Weight) IsInt() bool {
+	return w.r == nil || w.r.IsInt()
+}
+
+// Int returns w as an int64, and whether it is an integer that fits one.
+func (w Weight) Int() (int64, bool) {
+	return w.n, w.r == nil
+}
+
+// Float64 returns the nearest float64 to w.
+func (w Weight) Float64() float64 {
+	if w.r == nil {
+		return float64(w.n)
+	}
+	f, _ := w.r.Float64()
+	return f
+}
+
+// String returns w as a decimal number, with as many decimals as needed to write it exactly.
+func (w Weight) String() string {
+	if w.r == nil {
+		return strconv.FormatInt(w.n, 10)
+	}
+	return w.r.FloatString(w.Decimals())
+}
+
+// Decimals returns the number of decimals needed to write w exactly.
+func (w Weight) Decimals() int {
+	if w.r == nil {
+		return 0
+	}
+	// a decimal number has a denominator of 2^a * 5^b, and needs max(a, b) decimals
+	d := new(big.Int).Set(w.r.Denom())
+	twos := d.TrailingZeroBits()
+	d.Rsh(d, twos)
+	fives := uint(0)
+	five, m := big.NewInt(5), new(big.Int)
+	for d.Cmp(big.NewInt(1)) > 0 {
+		if d.DivMod(d, five, m); m.Sign() != 0 {
+			break
+		}
+		fives++
+	}
+	return int(max(twos, fives))
+}
+
+// Scale returns w * 10^decimals truncated to an integer, that is w exactly in units of 10^-decimals
+// when decimals is at least w.Decimals().
+func (w Weight) Scale(decimals int) *big.Int {
+	unit := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimals)), nil)
+	if w.r == nil {
+		return unit.Mul(unit, big.NewInt(w.n))
+	}
+	unit.Mul(unit, w.r.Num())
+	return unit.Quo(unit, w.r.Denom())
+}
+
+// MarshalJSON writes w as an exact JSON number.
+func (w Weight) MarshalJSON() ([]byte, error) {
+	return []byte(w.String()), nil
+}
+
+// UnmarshalJSON reads w from a JSON number, or a string holding one.
+func (w *Weight) UnmarshalJSON(data []byte) error {
+	s := string(data)
+	if strings.HasPrefix(s, `"`) {
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+	}
+	parsed, err := ParseWeight(s)
+	if err != nil {
+		return err
+	}
+	*w = parsed
+	return nil
+}
@@ -0,0 +1,127 @@
+package election
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func weight(t *testing.T, s string) Weight {
+	t.Helper()
+	w, err := ParseWeight(s)
+	require.NoError(t, err, s)
+	return w
+}
+
+func TestParseWeight(t *testing.T) {
+	tests := map[string]string{
+		"3":                                  "3",
+		"0":                                  "0",
+		"0.5":                                "0.5",
+		".25":                                "0.25",
+		"1.50":                               "1.5",
+		"1.5e6":                              "1500000",
+		"2.5E-3":                             "0.0025",
+		"123456789012345678901234567890.125": "123456789012345678901234567890.125",
+	}
+	for in, want := range tests {
+		assert.Equal(t, want, weight(t, in).String(), in)
+	}
+
+	for _, in := range []string{"-1", "-0.5", "NaN", "nan", "Inf", "+1", "1/3", "0x10", "1e999", "5.", ".", "", "1e", "1.2.3"} {
+		_, err := ParseWeight(in)
+		assert.Error(t, err, in)
+	}
+}
+
+func TestWeight_Exact(t *testing.T) {
+	sum := weight(t, "0.1").Add(weight(t, "0.2"))
+	assert.Equal(t, weight(t, "0.3"), sum)
+	assert.Equal(t, "0.3", sum.String())
+
+	// integers keep a single representation, however they are computed
+	assert.Equal(t, NewWeight(1), weight(t, "0.5").Add(weight(t, "0.5")))
+	assert.True(t, sum.Add(weight(t, "0.7")).IsInt())
+
+	big := NewWeight(1 << 62).Add(NewWeight(1 << 62))
+	assert.Equal(t, "9223372036854775808", big.String())
+	assert.True(t, big.IsInt())
+	_, ok := big.Int()
+	assert.False(t, ok)
+	assert.Equal(t, 1, big.Cmp(NewWeight(1<<62)))
+
+	assert.Equal(t, 0, Weight{}.Sign())
+	assert.Equal(t, 0.25, weight(t, "0.25").Float64())
+}
+
+func TestWeight_Scale(t *testing.T) {
+	tests := []struct {
+		in       string
+		decimals int
+		scaled   string
+	}{
+		{"3", 0, "3000000000"},
+		{"0.1", 1, "100000000"},
+		{"0.3", 1, "300000000"},
+		{"2.5e-3", 4, "2500000"},
+		{"0.0000000001", 10, "0"},
+		{"9007199254740993", 0, "9007199254740993000000000"},
+	}
+	for _, tt := range tests {
+		w := weight(t, tt.in)
+		assert.Equal(t, tt.decimals, w.Decimals(), tt.in)
+		assert.Equal(t, tt.scaled, w.Scale(9).String(), tt.in)
+	}
+}
+
+func TestWeight_JSON(t *testing.T) {
+	var ws []Weight
+	require.NoError(t, json.Unmarshal([]byte(`[1, 0.1, "2.5", 1e3]`), &ws))
+	assert.Equal(t, []Weight{NewWeight(1), weight(t, "0.1"), weight(t, "2.5"), NewWeight(1000)}, ws)
+
+	out, err := json.Marshal(ws)
+	require.NoError(t, err)
+	assert.Equal(t, `[1,0.1,2.5,1000]`, string(out))
+
+	for _, in := range []string{`-1`, `"NaN"`, `null`, `true`} {
+		var w Weight
+		assert.Error(t, json.Unmarshal([]byte(in), &w), in)
+	}
+}
+
+func TestFractionalWeights(t *testing.T) {
+	blt := "2 1\n0.5 1 2 0\n1.25 2 0\n0\n\"A\"\n\"B\"\n\"Weighted\"\n"
+	e, err := Parse(strings.NewReader(blt))
+	require.NoError(t, err)
+	assert.Equal(t, weight(t, "1.75"), e.TotalWeight())
+
+	buf := &bytes.Buffer{}
+	require.NoError(t, WriteBLT(buf, e))
+	assert.Equal(t, blt, buf.String())
+
+	buf.Reset()
+	require.NoError(t, WriteJSON(buf, e))
+	assert.Contains(t, buf.String(), `"weight": 0.5,`)
+	got, err := ReadJSON(buf)
+	require.NoError(t, err)
+	assert.Equal(t, e.Ballots, got.Ballots)
+
+	for name, input := range map[string]string{
+		"negative": "2 1\n-0.5 1 0\n0\n\"A\"\n\"B\"\n\"T\"\n",
+		"nan":      "2 1\nNaN 1 0\n0\n\"A\"\n\"B\"\n\"T\"\n",
+	} {
+		_, err := Parse(strings.NewReader(input))
+		assert.Error(t, err, name)
+	}
+	_, err = ReadJSON(strings.NewReader(`{"seats": 1, "candidates": ["A"], "ballots": [{"weight": -0.5, "preferences": [0]}]}`))
+	assert.Error(t, err)
+
+	// totals beyond 2^53, which a float64 can't hold exactly, are kept
+	e, err = Parse(strings.NewReader("2 1\n9007199254740991.5 1 0\n1 2 0\n0\n\"A\"\n\"B\"\n\"T\"\n"))
+	require.NoError(t, err)
+	assert.Equal(t, "9007199254740992.5", e.TotalWeight().String())
+}
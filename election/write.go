@@ -0,0 +1,63 @@
+package election
+
+import (
+	"bufio"
+	"io"
+	"sort"
+	"strconv"
+)
+
+// WriteBLT writes e in the OpaVote ballot file format read by Read.
+// The format has no room for seat classes nor ballot IDs, which are left out.
+func WriteBLT(w io.Writer, e *Election) error {
+	bw := bufio.NewWriter(w)
+
+	bw.WriteString(strconv.Itoa(e.Candidates) + " " + strconv.Itoa(e.Seats) + "\n")
+
+	if len(e.Withdrawn) > 0 {
+		withdrawn := make([]int, 0, len(e.Withdrawn))
+		for i, ok := range e.Withdrawn {
+			if ok {
+				withdrawn = append(withdrawn, i)
+			}
+		}
+		sort.Ints(withdrawn)
+		for i, c := range withdrawn {
+			if i > 0 {
+				bw.WriteByte(' ')
+			}
+			// withdrawn candidates are written 1-indexed and negated
+			bw.WriteString(strconv.Itoa(-(c + 1)))
+		}
+		bw.WriteByte('\n')
+	}
+
+	for _, b := range e.Ballots {
+		bw.WriteString(b.Weight.String())
+		for k, p := range b.Preferences {
+			if k < len(b.Equal) && b.Equal[k] {
+				bw.WriteByte('=')
+			} else {
+				bw.WriteByte(' ')
+			}
+			bw.WriteString(strconv.Itoa(p + 1))
+		}
+		bw.WriteString(" 0\n")
+	}
+	bw.WriteString("0\n")
+
+	for _, name := range e.CandidateNames {
+		bw.WriteString(quote(name) + "\n")
+	}
+	bw.WriteString(quote(e.Title) + "\n")
+
+	return bw.Flush()
+}
+
+// quote puts double quotes around a candidate name or title, unless it is read with them
+func quote(s string) string {
+	if unquote(s) != s {
+		return s
+	}
+	return `"` + s + `"`
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/linuxfoundation-it/meek-stv/flow"
+	"github.com/linuxfoundation-it/meek-stv/meekstv"
+)
+
+func flowCmd(args []string, env *env) int {
+	fs := newFlagSet("flow", "[ballot file]", "Counts an election and exports the vote transfers between rounds as a Sankey diagram.", env)
+	cf := addCountFlags(fs)
+	format := fs.String("format", "json", "output format: json (nodes and links) or svg")
+
+	positional, err := parseFlags(fs, args)
+	if err != nil {
+		return usageError(err)
+	}
+	if len(positional) > 1 || *cf.seats < 0 {
+		fs.Usage()
+		return exitUsage
+	}
+
+	e, code := cf.load(first(positional), env)
+	if e == nil {
+		return code
+	}
+	report := meekstv.Count(e)
+	sankey := flow.Build(&report, e)
+
+	switch *format {
+	case "json":
+		err = sankey.WriteJSON(env.stdout)
+	case "svg":
+		err = sankey.WriteSVG(env.stdout)
+	default:
+		fmt.Fprintf(env.stderr, "unknown output format %q\n", *format)
+		return exitUsage
+	}
+	if err != nil {
+		fmt.Fprintln(env.stderr, err)
+		return exitFailure
+	}
+	return exitOK
+}
@@ -0,0 +1,78 @@
+package flow
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/linuxfoundation-it/meek-stv/election"
+	"github.com/linuxfoundation-it/meek-stv/meekstv"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func readElection(t *testing.T, path string) *election.Election {
+	t.Helper()
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+
+	e, err := election.Parse(f)
+	require.NoError(t, err)
+	e.Unquote()
+	return e
+}
+
+// tolerance relative to the number of votes
+func tolerance(e *election.Election) float64 {
+	return 1e-9 * math.Max(e.TotalWeight().Float64(), 1)
+}
+
+func TestBuild(t *testing.T) {
+	e := readElection(t, "../testdata/election12.txt")
+	report := meekstv.Count(e)
+	s := Build(&report, e)
+	tol := tolerance(e)
+
+	in := make([]float64, len(s.Nodes))
+	out := make([]float64, len(s.Nodes))
+	for _, l := range s.Links {
+		assert.Equal(t, s.Nodes[l.Source].Round+1, s.Nodes[l.Target].Round)
+		out[l.Source] += l.Value
+		in[l.Target] += l.Value
+	}
+	last := report.Round(report.NumRounds() - 1).Round
+	for i, n := range s.Nodes {
+		if n.Round > 0 {
+			assert.InDelta(t, n.Value, in[i], 1e-3+tol, "inflow of %s", n.ID)
+		}
+		if n.Round < last {
+			assert.InDelta(t, n.Value, out[i], 1e-3+tol, "outflow of %s", n.ID)
+		}
+	}
+
+	var b bytes.Buffer
+	require.NoError(t, s.WriteJSON(&b))
+	var decoded Sankey
+	require.NoError(t, json.Unmarshal(b.Bytes(), &decoded))
+	assert.Equal(t, s, &decoded)
+}
+
+func TestWriteSVG(t *testing.T) {
+	e := readElection(t, "../testdata/election13.txt")
+	e.CandidateNames[0] = "Zoe <&>"
+	report := meekstv.Count(e)
+	s := Build(&report, e)
+
+	var b bytes.Buffer
+	require.NoError(t, s.WriteSVG(&b))
+	out := b.String()
+	assert.True(t, strings.HasPrefix(out, `<svg xmlns="http://www.w3.org/2000/svg"`))
+	assert.Equal(t, len(s.Links), strings.Count(out, "<path "))
+	assert.Equal(t, len(s.Nodes), strings.Count(out, "<rect "))
+	assert.Contains(t, out, "Zoe &lt;&amp;&gt;")
+	assert.NotContains(t, out, "NaN")
+}
@@ -0,0 +1,207 @@
+// Package flow exports the vote transfers between the rounds of a MeekSTV count as Sankey diagrams.
+package flow
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"math"
+	"strings"
+
+	"github.com/linuxfoundation-it/meek-stv/election"
+	"github.com/linuxfoundation-it/meek-stv/meekstv"
+)
+
+// amounts below epsilon are rounding noise and are left out of diagrams
+const epsilon = 1e-6
+
+// Sankey is a flow diagram of the votes of every candidate across rounds, in the
+// nodes and links layout of common Sankey libraries such as d3-sankey.
+type Sankey struct {
+	Title string `json:"title"`
+	Nodes []Node `json:"nodes"`
+	Links []Link `json:"links"`
+}
+
+// Node is the votes of a candidate, or the exhausted votes, in a round.
+type Node struct {
+	ID    string `json:"id"`
+	Name  string `json:"name"`
+	Round int    `json:"round"`
+	// Candidate is the index of the candidate, or -1 for exhausted votes
+	Candidate int     `json:"candidate"`
+	Value     float64 `json:"value"`
+}
+
+// Link carries votes from a node of a round to a node of the next one. Source and Target
+// are indices in Nodes. Kind is "kept" for the votes a candidate keeps from a round to the
+// next, "transfer" for votes given to another candidate and "exhausted" for votes that exhaust.
+type Link struct {
+	Source int     `json:"source"`
+	Target int     `json:"target"`
+	Value  float64 `json:"value"`
+	Kind   string  `json:"kind"`
+}
+
+// Build returns the Sankey diagram of the count l of election e.
+func Build(l *meekstv.Log, e *election.Election) *Sankey {
+	s := &Sankey{Title: e.Title}
+
+	// nodes[i][c] is the index in s.Nodes of candidate c in round i, with c == e.Candidates
+	// for exhausted votes, or -1 if there is no such node
+	nodes := make([][]int, l.NumRounds())
+	for i := range nodes {
+		entry := l.Round(i)
+		nodes[i] = make([]int, e.Candidates+1)
+		for c := 0; c <= e.Candidates; c++ {
+			nodes[i][c] = -1
+			n := Node{
+				ID:        fmt.Sprintf("r%d-c%d", entry.Round, c),
+				Round:     entry.Round,
+				Candidate: c,
+			}
+			if c == e.Candidates {
+				n.ID = fmt.Sprintf("r%d-exhausted", entry.Round)
+				n.Name = "Exhausted"
+				n.Candidate = -1
+				n.Value = entry.Exhausted
+			} else {
+				n.Name = e.CandidateNames[c]
+				n.Value = entry.VotesOf(c)
+			}
+			if n.Value > epsilon {
+				nodes[i][c] = len(s.Nodes)
+				s.Nodes = append(s.Nodes, n)
+			}
+		}
+	}
+
+	link := func(from, to int, value float64, kind string) {
+		if from >= 0 && to >= 0 && value > epsilon {
+			s.Links = append(s.Links, Link{Source: from, Target: to, Value: value, Kind: kind})
+		}
+	}
+	for i := 1; i < l.NumRounds(); i++ {
+		m := l.Round(i).Transfers
+		prev, cur := nodes[i-1], nodes[i]
+		for src := 0; src <= e.Candidates; src++ {
+			if prev[src] < 0 {
+				continue
+			}
+			kept := s.Nodes[prev[src]].Value - m.Outflow(src)
+			link(prev[src], cur[src], kept, "kept")
+			for dst := 0; dst < e.Candidates; dst++ {
+				if dst != src {
+					link(prev[src], cur[dst], m.At(src, dst), "transfer")
+				}
+			}
+			if src != e.Candidates {
+				link(prev[src], cur[e.Candidates], m.At(src, e.Candidates), "exhausted")
+			}
+		}
+	}
+	return s
+}
+
+func (s *Sankey) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(s)
+}
+
+// diagram geometry, in SVG user units
+const (
+	svgHeight   = 600
+	columnWidth = 160
+	nodeWidth   = 12
+	nodeGap     = 10
+	margin      = 20
+	labelWidth  = 120
+)
+
+var palette = []string{
+	"#4e79a7", "#f28e2b", "#e15759", "#76b7b2", "#59a14f",
+	"#edc948", "#b07aa1", "#ff9da7", "#9c755f", "#bab0ac",
+}
+
+const exhaustedColor = "#888888"
+
+// WriteSVG draws the diagram as a standalone SVG image, with a column per round.
+func (s *Sankey) WriteSVG(w io.Writer) error {
+	rounds := 0
+	columns := make(map[int][]int)
+	for i, n := range s.Nodes {
+		columns[n.Round] = append(columns[n.Round], i)
+		rounds = max(rounds, n.Round+1)
+	}
+
+	// the tallest column fills the height of the image
+	tallest, crowded := 0.0, 0
+	for _, col := range columns {
+		sum := 0.0
+		for _, i := range col {
+			sum += s.Nodes[i].Value
+		}
+		tallest = max(tallest, sum)
+		crowded = max(crowded, len(col))
+	}
+	scale := 0.0
+	if tallest > 0 {
+		scale = (svgHeight - 2*margin - float64(max(crowded-1, 0))*nodeGap) / tallest
+	}
+
+	// layout: nodes are stacked top to bottom in each column, in candidate order with exhausted last
+	type box struct{ x, y, h, out, in float64 }
+	boxes := make([]box, len(s.Nodes))
+	for r := 0; r < rounds; r++ {
+		y := float64(margin)
+		for _, i := range columns[r] {
+			h := s.Nodes[i].Value * scale
+			boxes[i] = box{x: float64(margin + r*columnWidth), y: y, h: h}
+			y += h + nodeGap
+		}
+	}
+
+	width := margin*2 + max(rounds-1, 0)*columnWidth + nodeWidth + labelWidth
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" font-family="sans-serif" font-size="10">`+"\n",
+		width, svgHeight, width, svgHeight)
+	fmt.Fprintf(&b, "<title>%s</title>\n", html.EscapeString(s.Title))
+
+	for _, l := range s.Links {
+		src, dst := &boxes[l.Source], &boxes[l.Target]
+		h := l.Value * scale
+		x0, y0 := src.x+nodeWidth, src.y+src.out
+		x1, y1 := dst.x, dst.y+dst.in
+		xm := (x0 + x1) / 2
+		src.out += h
+		dst.in += h
+		fmt.Fprintf(&b, `<path d="M%.2f %.2fC%.2f %.2f %.2f %.2f %.2f %.2fL%.2f %.2fC%.2f %.2f %.2f %.2f %.2f %.2fZ" fill="%s" fill-opacity="0.35"><title>%s → %s: %.2f</title></path>`+"\n",
+			x0, y0, xm, y0, xm, y1, x1, y1,
+			x1, y1+h, xm, y1+h, xm, y0+h, x0, y0+h,
+			color(s.Nodes[l.Source].Candidate),
+			html.EscapeString(s.Nodes[l.Source].Name), html.EscapeString(s.Nodes[l.Target].Name), l.Value)
+	}
+
+	for i, n := range s.Nodes {
+		bx := boxes[i]
+		fmt.Fprintf(&b, `<rect x="%.2f" y="%.2f" width="%d" height="%.2f" fill="%s"><title>%s, round %d: %.2f</title></rect>`+"\n",
+			bx.x, bx.y, nodeWidth, math.Max(bx.h, 0.5), color(n.Candidate), html.EscapeString(n.Name), n.Round, n.Value)
+		if bx.h >= 8 {
+			fmt.Fprintf(&b, `<text x="%.2f" y="%.2f" dominant-baseline="middle" stroke="white" stroke-width="3" paint-order="stroke">%s</text>`+"\n",
+				bx.x+nodeWidth+3, bx.y+bx.h/2, html.EscapeString(n.Name))
+		}
+	}
+	b.WriteString("</svg>\n")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func color(candidate int) string {
+	if candidate < 0 {
+		return exhaustedColor
+	}
+	return palette[candidate%len(palette)]
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/linuxfoundation-it/meek-stv/election"
+	"github.com/linuxfoundation-it/meek-stv/generate"
+)
+
+func generateCmd(args []string, env *env) int {
+	fs := newFlagSet("generate", "", "Generates a random election from a voter model and writes it as a ballot file.", env)
+	model := fs.String("model", "ic", "voter model: ic (impartial culture), mallows, spatial or party")
+	seed := fs.Uint64("seed", 1, "random seed, the same seed generates the same election")
+	candidates := fs.Int("candidates", 6, "number of candidates")
+	seats := fs.Int("seats", 2, "number of seats")
+	ballots := fs.Int("ballots", 1000, "number of ballots")
+	truncation := fs.Float64("truncation", 0, "probability that a ballot is truncated")
+	withdraw := fs.String("withdraw", "", "comma separated numbers (1-based) of withdrawn candidates")
+	phi := fs.Float64("phi", 0.5, "dispersion of the mallows model")
+	dimensions := fs.Int("dimensions", 2, "dimensions of the spatial model")
+	parties := fs.Int("parties", 2, "number of parties of the party model")
+	loyalty := fs.Float64("loyalty", 0.5, "probability that a voter of the party model ranks only their party")
+	format := fs.String("format", "blt", "output format: blt or json")
+	title := fs.String("title", "", "election title")
+
+	positional, err := parseFlags(fs, args)
+	if err != nil {
+		return usageError(err)
+	}
+	if len(positional) > 0 {
+		fs.Usage()
+		return exitUsage
+	}
+	if *format != "blt" && *format != "json" {
+		fmt.Fprintf(env.stderr, "unknown output format %q\n", *format)
+		return exitUsage
+	}
+	// the candidates are only known by number before they are generated
+	withdrawn, err := parseCandidates(*withdraw, &election.Election{Candidates: *candidates})
+	if err != nil {
+		fmt.Fprintln(env.stderr, err)
+		return exitUsage
+	}
+
+	var m generate.Model
+	switch *model {
+	case "ic":
+		m = generate.ImpartialCulture{}
+	case "mallows":
+		m = generate.Mallows{Phi: *phi}
+	case "spatial":
+		m = generate.Spatial{Dimensions: *dimensions}
+	case "party":
+		m = generate.PartyBlocs{Parties: *parties, Loyalty: *loyalty}
+	default:
+		fmt.Fprintf(env.stderr, "unknown model %q\n", *model)
+		return exitUsage
+	}
+
+	e, err := generate.Generate(generate.Config{
+		Title:      *title,
+		Seed:       *seed,
+		Candidates: *candidates,
+		Seats:      *seats,
+		Ballots:    *ballots,
+		Truncation: *truncation,
+		Withdrawn:  withdrawn,
+		Model:      m,
+	})
+	if err != nil {
+		fmt.Fprintln(env.stderr, err)
+		return exitUsage
+	}
+
+	if *format == "json" {
+		err = election.WriteJSON(env.stdout, e)
+	} else {
+		err = election.WriteBLT(env.stdout, e)
+	}
+	if err != nil {
+		fmt.Fprintln(env.stderr, err)
+		return exitFailure
+	}
+	return exitOK
+}
@@ -0,0 +1,76 @@
+// Package generate produces synthetic elections from configurable voter models,
+// for fuzzing, benchmarking and teaching.
+package generate
+
+import (
+	"errors"
+	"fmt"
+	"math/rand/v2"
+
+	"github.com/linuxfoundation-it/meek-stv/election"
+)
+
+type Config struct {
+	Title      string
+	Seed       uint64
+	Candidates int
+	Seats      int
+	// Ballots is the number of ballots, each one with weight 1
+	Ballots int
+	// Truncation is the probability in [0, 1] that a voter stops ranking
+	// before the end of their ranking
+	Truncation float64
+	// Withdrawn lists zero-based indices of withdrawn candidates. Voters still rank them.
+	Withdrawn []int
+	// Model draws voters' rankings. Defaults to ImpartialCulture.
+	Model Model
+}
+
+// Generate returns a new election drawn from cfg. The same configuration
+// always generates the same election.
+func Generate(cfg Config) (*election.Election, error) {
+	if cfg.Candidates <= 0 {
+		return nil, errors.New("at least one candidate is required")
+	}
+	if cfg.Seats < 0 || cfg.Ballots < 0 {
+		return nil, errors.New("seats and ballots must not be negative")
+	}
+	if cfg.Truncation < 0 || cfg.Truncation > 1 {
+		return nil, fmt.Errorf("truncation rate %v is not in [0, 1]", cfg.Truncation)
+	}
+	if cfg.Model == nil {
+		cfg.Model = ImpartialCulture{}
+	}
+	if cfg.Title == "" {
+		cfg.Title = "Synthetic Election"
+	}
+
+	e := &election.Election{
+		Title:          cfg.Title,
+		Candidates:     cfg.Candidates,
+		Seats:          cfg.Seats,
+		Withdrawn:      make(map[int]bool),
+		Ballots:        make([]election.Ballot, 0, cfg.Ballots),
+		CandidateNames: make([]string, cfg.Candidates),
+	}
+	for i := range e.CandidateNames {
+		e.CandidateNames[i] = fmt.Sprintf("Candidate %d", i+1)
+	}
+	for _, c := range cfg.Withdrawn {
+		if c < 0 || c >= cfg.Candidates {
+			return nil, fmt.Errorf("withdrawn candidate %d out of range", c)
+		}
+		e.Withdrawn[c] = true
+	}
+
+	rng := rand.New(rand.NewPCG(cfg.Seed, 0))
+	draw := cfg.Model.Sampler(rng, cfg.Candidates)
+	for i := 0; i < cfg.Ballots; i++ {
+		ranking := draw()
+		if len(ranking) > 1 && rng.Float64() < cfg.Truncation {
+			ranking = ranking[:1+rng.IntN(len(ranking)-1)]
+		}
+		e.Ballots = append(e.Ballots, election.Ballot{Weight: election.NewWeight(1), Preferences: ranking})
+	}
+	return e, nil
+}
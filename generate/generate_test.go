@@ -0,0 +1,78 @@
+package generate
+
+import (
+	"testing"
+
+	"github.com/linuxfoundation-it/meek-stv/election"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerate(t *testing.T) {
+	models := map[string]Model{
+		"impartial culture": ImpartialCulture{},
+		"mallows":           Mallows{Phi: 0.3},
+		"spatial":           Spatial{Dimensions: 3},
+		"party blocs":       PartyBlocs{Parties: 3, Loyalty: 0.5},
+	}
+	for name, m := range models {
+		t.Run(name, func(t *testing.T) {
+			cfg := Config{Seed: 3, Candidates: 7, Seats: 2, Ballots: 200, Truncation: 0.3, Withdrawn: []int{4}, Model: m}
+
+			e, err := Generate(cfg)
+			require.NoError(t, err)
+
+			assert.Equal(t, 7, e.Candidates)
+			assert.Equal(t, 2, e.Seats)
+			assert.Len(t, e.CandidateNames, 7)
+			assert.Len(t, e.Ballots, 200)
+			assert.True(t, e.Withdrawn[4])
+
+			for _, b := range e.Ballots {
+				assert.Equal(t, election.NewWeight(1), b.Weight)
+				assert.NotEmpty(t, b.Preferences)
+				seen := make(map[int]bool)
+				for _, p := range b.Preferences {
+					assert.False(t, seen[p], "candidate %d ranked twice", p)
+					assert.True(t, p >= 0 && p < 7)
+					seen[p] = true
+				}
+			}
+
+			again, err := Generate(cfg)
+			require.NoError(t, err)
+			assert.Equal(t, e, again, "same seed must generate the same election")
+		})
+	}
+}
+
+func TestGenerate_Truncation(t *testing.T) {
+	e, err := Generate(Config{Seed: 1, Candidates: 5, Seats: 1, Ballots: 100})
+	require.NoError(t, err)
+	for _, b := range e.Ballots {
+		assert.Len(t, b.Preferences, 5)
+	}
+
+	e, err = Generate(Config{Seed: 1, Candidates: 5, Seats: 1, Ballots: 100, Truncation: 1})
+	require.NoError(t, err)
+	for _, b := range e.Ballots {
+		assert.Less(t, len(b.Preferences), 5)
+	}
+}
+
+func TestMallows_ZeroDispersion(t *testing.T) {
+	e, err := Generate(Config{Seed: 9, Candidates: 4, Ballots: 10, Model: Mallows{Phi: 0, Reference: []int{2, 0, 3, 1}}})
+	require.NoError(t, err)
+	for _, b := range e.Ballots {
+		assert.Equal(t, []int{2, 0, 3, 1}, b.Preferences)
+	}
+}
+
+func TestGenerate_InvalidConfig(t *testing.T) {
+	_, err := Generate(Config{Candidates: 0})
+	assert.Error(t, err)
+	_, err = Generate(Config{Candidates: 3, Truncation: 2})
+	assert.Error(t, err)
+	_, err = Generate(Config{Candidates: 3, Withdrawn: []int{3}})
+	assert.Error(t, err)
+}
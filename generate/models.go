@@ -0,0 +1,172 @@
+package generate
+
+import (
+	"math"
+	"math/rand/v2"
+	"sort"
+)
+
+// Model describes how voters rank candidates.
+type Model interface {
+	// Sampler prepares the model for an election with n candidates and returns
+	// a function that draws one voter's ranking of candidate indices on every call.
+	Sampler(rng *rand.Rand, n int) func() []int
+}
+
+// ImpartialCulture ranks candidates uniformly at random.
+type ImpartialCulture struct{}
+
+func (ImpartialCulture) Sampler(rng *rand.Rand, n int) func() []int {
+	return func() []int {
+		return rng.Perm(n)
+	}
+}
+
+// Mallows draws rankings concentrated around a reference ranking.
+// Phi in [0, 1] is the dispersion: 0 always yields the reference ranking,
+// 1 is the same as ImpartialCulture.
+type Mallows struct {
+	Phi float64
+	// Reference defaults to candidates in index order
+	Reference []int
+}
+
+func (m Mallows) Sampler(rng *rand.Rand, n int) func() []int {
+	ref := m.Reference
+	if len(ref) != n {
+		ref = make([]int, n)
+		for i := range ref {
+			ref[i] = i
+		}
+	}
+
+	// repeated insertion model: the i-th reference candidate is inserted at
+	// position j (0 <= j <= i) with probability proportional to phi^(i-j)
+	cumulative := make([][]float64, n)
+	for i := range cumulative {
+		cumulative[i] = make([]float64, i+1)
+		tot := 0.0
+		for j := 0; j <= i; j++ {
+			tot += math.Pow(m.Phi, float64(i-j))
+			cumulative[i][j] = tot
+		}
+	}
+
+	return func() []int {
+		out := make([]int, 0, n)
+		for i, c := range ref {
+			x := rng.Float64() * cumulative[i][i]
+			j := sort.SearchFloat64s(cumulative[i], x)
+			if j > i {
+				j = i
+			}
+			out = append(out, 0)
+			copy(out[j+1:], out[j:])
+			out[j] = c
+		}
+		return out
+	}
+}
+
+// Spatial places candidates and voters uniformly at random in a unit hypercube
+// and has each voter rank candidates by increasing Euclidean distance.
+type Spatial struct {
+	// Dimensions defaults to 2
+	Dimensions int
+}
+
+func (s Spatial) Sampler(rng *rand.Rand, n int) func() []int {
+	dims := s.Dimensions
+	if dims <= 0 {
+		dims = 2
+	}
+	point := func() []float64 {
+		p := make([]float64, dims)
+		for i := range p {
+			p[i] = rng.Float64()
+		}
+		return p
+	}
+
+	candidates := make([][]float64, n)
+	for i := range candidates {
+		candidates[i] = point()
+	}
+
+	return func() []int {
+		voter := point()
+		dist := make([]float64, n)
+		for i, c := range candidates {
+			for d := range c {
+				dist[i] += (c[d] - voter[d]) * (c[d] - voter[d])
+			}
+		}
+		out := make([]int, n)
+		for i := range out {
+			out[i] = i
+		}
+		sort.SliceStable(out, func(i, j int) bool {
+			return dist[out[i]] < dist[out[j]]
+		})
+		return out
+	}
+}
+
+// PartyBlocs splits candidates into parties, each presenting an ordered list.
+// Voters pick a party, rank its list in order, and then either stop or
+// go on ranking the other parties' candidates at random.
+type PartyBlocs struct {
+	// Parties defaults to 2. Candidate i belongs to party i % Parties.
+	Parties int
+	// Shares is the probability of a voter supporting each party.
+	// Defaults to random shares.
+	Shares []float64
+	// Loyalty is the probability in [0, 1] that a voter ranks only their party's candidates.
+	Loyalty float64
+}
+
+func (p PartyBlocs) Sampler(rng *rand.Rand, n int) func() []int {
+	parties := p.Parties
+	if parties <= 0 {
+		parties = 2
+	}
+	if parties > n {
+		parties = n
+	}
+
+	lists := make([][]int, parties)
+	for i := 0; i < n; i++ {
+		lists[i%parties] = append(lists[i%parties], i)
+	}
+
+	shares := p.Shares
+	if len(shares) != parties {
+		shares = make([]float64, parties)
+		for i := range shares {
+			shares[i] = rng.Float64()
+		}
+	}
+	cumulative := make([]float64, parties)
+	tot := 0.0
+	for i, s := range shares {
+		tot += s
+		cumulative[i] = tot
+	}
+
+	return func() []int {
+		party := sort.SearchFloat64s(cumulative, rng.Float64()*tot)
+		if party >= parties {
+			party = parties - 1
+		}
+		out := append(make([]int, 0, n), lists[party]...)
+		if rng.Float64() < p.Loyalty {
+			return out
+		}
+		for _, c := range rng.Perm(n) {
+			if c%parties != party {
+				out = append(out, c)
+			}
+		}
+		return out
+	}
+}
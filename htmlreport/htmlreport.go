@@ -0,0 +1,190 @@
+// Package htmlreport renders the results of a MeekSTV count as a single static HTML page,
+// with styles and charts inline so that the file can be published as is.
+package htmlreport
+
+import (
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/linuxfoundation-it/meek-stv/election"
+	"github.com/linuxfoundation-it/meek-stv/meekstv"
+)
+
+type Options struct {
+	// Precision is the number of decimals of votes, keep factors and thresholds.
+	Precision int
+}
+
+var DefaultOptions = Options{Precision: 2}
+
+// chart geometry, in SVG user units
+const (
+	barWidth  = 240
+	barHeight = 14
+)
+
+type page struct {
+	Title   string
+	Seats   int
+	Ballots election.Weight
+	Winners []winner
+	Rounds  []round
+}
+
+type winner struct {
+	Name  string
+	Votes string
+	// Round is empty for candidates elected at the end of the count to fill the remaining seats
+	Round string
+	votes float64
+}
+
+type round struct {
+	Number     int
+	Threshold  string
+	Share      string
+	Total      string
+	Exhausted  string
+	Candidates []row
+	ThresholdX float64
+	Transfers  []transfers
+	Elected    []string
+	Defeated   []string
+}
+
+type row struct {
+	Name       string
+	State      string
+	Event      string
+	KeepFactor string
+	Votes      string
+	BarWidth   float64
+}
+
+type transfers struct {
+	Kind      string
+	Received  []transfer
+	Exhausted string
+}
+
+type transfer struct {
+	Name  string
+	Votes string
+}
+
+// Write renders the count l of election e as an HTML page to w.
+func Write(w io.Writer, l *meekstv.Log, e *election.Election, opts Options) error {
+	return tmpl.Execute(w, build(l, e, opts))
+}
+
+func build(l *meekstv.Log, e *election.Election, opts Options) *page {
+	num := func(f float64) string {
+		return fmt.Sprintf("%.*f", opts.Precision, f)
+	}
+
+	p := &page{
+		Title: e.Title,
+		Seats: e.Seats,
+	}
+	p.Ballots = e.TotalWeight()
+
+	electedIn := make(map[int]string)
+	for i := 0; i < l.NumRounds(); i++ {
+		entry := l.Round(i)
+		for _, c := range entry.Elected {
+			electedIn[c.Index] = fmt.Sprint(entry.Round)
+		}
+
+		r := round{
+			Number:    entry.Round,
+			Threshold: num(entry.Threshold),
+			Total:     num(entry.TotVotes),
+			Exhausted: num(entry.Exhausted),
+			Elected:   names(entry.Elected),
+			Defeated:  names(entry.Defeated),
+		}
+		if entry.TotVotes > 0 {
+			r.Share = fmt.Sprintf("%.*f%%", opts.Precision, entry.Threshold/entry.TotVotes*100)
+		}
+
+		// bars are scaled on the largest of the votes and the threshold, so that the threshold line is always visible
+		scale := entry.Threshold
+		for _, c := range entry.CandidateSnapshot {
+			scale = max(scale, c.Votes)
+		}
+		if scale > 0 {
+			r.ThresholdX = entry.Threshold / scale * barWidth
+		}
+
+		events := make(map[int]string)
+		for _, c := range entry.Elected {
+			events[c.Index] = "elected"
+		}
+		for _, c := range entry.Defeated {
+			events[c.Index] = "defeated"
+		}
+		for _, c := range entry.CandidateSnapshot {
+			rw := row{
+				Name:       c.Name,
+				State:      c.State.String(),
+				Event:      events[c.Index],
+				KeepFactor: num(c.KeepFactor),
+				Votes:      num(c.Votes),
+			}
+			if scale > 0 {
+				rw.BarWidth = c.Votes / scale * barWidth
+			}
+			r.Candidates = append(r.Candidates, rw)
+		}
+
+		if entry.SurplusReceived != nil {
+			r.Transfers = append(r.Transfers, transfersOf("Surplus", entry.SurplusReceived, entry.SurplusExhaustedDelta, entry.CandidateSnapshot, num))
+		}
+		if entry.EliminationReceived != nil {
+			r.Transfers = append(r.Transfers, transfersOf("Elimination", entry.EliminationReceived, entry.EliminationExhaustedDelta, entry.CandidateSnapshot, num))
+		}
+		p.Rounds = append(p.Rounds, r)
+	}
+
+	if l.NumRounds() > 0 {
+		results := l.Results()
+		for _, idx := range l.Winners() {
+			p.Winners = append(p.Winners, winner{
+				Name:  results[idx].Name,
+				Votes: num(results[idx].Votes),
+				Round: electedIn[idx],
+				votes: results[idx].Votes,
+			})
+		}
+		sort.SliceStable(p.Winners, func(i, j int) bool {
+			return p.Winners[i].votes > p.Winners[j].votes
+		})
+	}
+	return p
+}
+
+func transfersOf(kind string, received map[int]float64, exhausted float64, snapshot []meekstv.Candidate, num func(float64) string) transfers {
+	idxs := make([]int, 0, len(received))
+	for idx := range received {
+		idxs = append(idxs, idx)
+	}
+	sort.Ints(idxs)
+
+	t := transfers{Kind: kind}
+	for _, idx := range idxs {
+		t.Received = append(t.Received, transfer{Name: snapshot[idx].Name, Votes: num(received[idx])})
+	}
+	if exhausted > 0 {
+		t.Exhausted = num(exhausted)
+	}
+	return t
+}
+
+func names(cs []meekstv.Candidate) []string {
+	out := make([]string, 0, len(cs))
+	for _, c := range cs {
+		out = append(out, c.Name)
+	}
+	return out
+}
@@ -0,0 +1,84 @@
+package htmlreport
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/linuxfoundation-it/meek-stv/election"
+	"github.com/linuxfoundation-it/meek-stv/meekstv"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func readElection(t *testing.T, path string) *election.Election {
+	t.Helper()
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+
+	e, err := election.Parse(f)
+	require.NoError(t, err)
+	e.Unquote()
+	return e
+}
+
+func TestWrite(t *testing.T) {
+	e := readElection(t, "../testdata/election13.txt")
+	report := meekstv.Count(e)
+
+	var b bytes.Buffer
+	require.NoError(t, Write(&b, &report, e, DefaultOptions))
+	out := b.String()
+
+	assert.True(t, strings.HasPrefix(out, "<!DOCTYPE html>"))
+	assert.Contains(t, out, "<h1>Stack Overflow Moderator Election 2021</h1>")
+	assert.Contains(t, out, "<tr><td>Stephen Rauch</td><td class=\"num\">9356.00</td><td class=\"num\">2</td></tr>")
+	assert.Contains(t, out, `<section id="round-2">`)
+	assert.Contains(t, out, "Eliminated: Daniel Widdis")
+	assert.Contains(t, out, "Elimination transfers:")
+	assert.Contains(t, out, `<tr class="elected">`)
+	assert.Contains(t, out, `<tr class="defeated">`)
+	assert.Equal(t, report.NumRounds(), strings.Count(out, "<section "))
+
+	// the page is self-contained
+	for _, external := range []string{"<link", "<script", "src=", "@import", "url("} {
+		assert.NotContains(t, out, external)
+	}
+}
+
+func TestWrite_Escapes(t *testing.T) {
+	e := readElection(t, "../testdata/election13.txt")
+	e.Title = "<script>alert(1)</script>"
+	e.CandidateNames[0] = `Zoe "<b>"`
+	report := meekstv.Count(e)
+
+	var b bytes.Buffer
+	require.NoError(t, Write(&b, &report, e, DefaultOptions))
+	assert.NotContains(t, b.String(), "<script>")
+	assert.NotContains(t, b.String(), "<b>")
+}
+
+func TestBuild_Bars(t *testing.T) {
+	e := readElection(t, "../testdata/election13.txt")
+	report := meekstv.Count(e)
+
+	p := build(&report, e, Options{Precision: 0})
+	require.Len(t, p.Rounds, report.NumRounds())
+	assert.Equal(t, election.NewWeight(28331), p.Ballots)
+
+	for _, r := range p.Rounds {
+		assert.LessOrEqual(t, r.ThresholdX, float64(barWidth))
+		widest := 0.0
+		for _, c := range r.Candidates {
+			assert.LessOrEqual(t, c.BarWidth, float64(barWidth))
+			widest = max(widest, c.BarWidth)
+		}
+		// the longest bar, or the threshold, spans the whole chart
+		assert.InDelta(t, barWidth, max(widest, r.ThresholdX), 1e-9)
+	}
+	require.Len(t, p.Winners, 2)
+	assert.Equal(t, "Stephen Rauch", p.Winners[0].Name)
+	assert.Equal(t, "9356", p.Winners[0].Votes)
+}
@@ -0,0 +1,92 @@
+package htmlreport
+
+import (
+	"html/template"
+)
+
+var tmpl = template.Must(template.New("report").Funcs(template.FuncMap{
+	"barWidth":  func() int { return barWidth },
+	"barHeight": func() int { return barHeight },
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}} — results</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; color: #222; max-width: 60em; margin: 2em auto; padding: 0 1em; }
+h1 { font-size: 1.6em; }
+h2 { font-size: 1.2em; margin-top: 2em; border-bottom: 1px solid #ddd; padding-bottom: .2em; }
+table { border-collapse: collapse; margin: .5em 0; }
+th, td { padding: .25em .75em; text-align: left; }
+td.num, th.num { text-align: right; font-variant-numeric: tabular-nums; }
+tr.elected { background: #e3f5e1; }
+tr.defeated { background: #fbe3e3; }
+tr.state-withdrawn, tr.state-defeated { color: #999; }
+.summary { background: #f6f8fa; padding: .5em 1em; border-radius: 4px; }
+.event { font-weight: bold; }
+svg .bar { fill: #4a7dbd; }
+tr.elected svg .bar { fill: #3a9a3a; }
+tr.defeated svg .bar { fill: #c94c4c; }
+svg .threshold { stroke: #222; stroke-width: 1.5; stroke-dasharray: 3 2; }
+ul.transfers { margin: .25em 0; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<div class="summary">
+<p>{{.Seats}} seats, {{.Ballots}} ballots, {{len .Rounds}} rounds.</p>
+{{- if .Winners}}
+<table>
+<thead><tr><th>Elected</th><th class="num">Votes</th><th class="num">Round</th></tr></thead>
+<tbody>
+{{- range .Winners}}
+<tr><td>{{.Name}}</td><td class="num">{{.Votes}}</td><td class="num">{{.Round}}</td></tr>
+{{- end}}
+</tbody>
+</table>
+{{- else}}
+<p>No candidate is elected.</p>
+{{- end}}
+</div>
+{{- range .Rounds}}
+<section id="round-{{.Number}}">
+<h2>Round {{.Number}}</h2>
+<p>Threshold <strong>{{.Threshold}}</strong>{{if .Share}} ({{.Share}} of {{.Total}} votes){{end}}, exhausted {{.Exhausted}}.</p>
+<table>
+<thead><tr><th>Candidate</th><th>State</th><th class="num">Keep factor</th><th class="num">Votes</th><th></th></tr></thead>
+<tbody>
+{{- $x := .ThresholdX}}
+{{- range .Candidates}}
+<tr class="{{if .Event}}{{.Event}}{{else}}state-{{.State}}{{end}}">
+<td>{{.Name}}</td>
+<td>{{if .Event}}<span class="event">{{.Event}}</span>{{else}}{{.State}}{{end}}</td>
+<td class="num">{{.KeepFactor}}</td>
+<td class="num">{{.Votes}}</td>
+<td><svg width="{{barWidth}}" height="{{barHeight}}" viewBox="0 0 {{barWidth}} {{barHeight}}" role="img" aria-label="{{.Votes}} votes"><rect class="bar" x="0" y="0" width="{{printf "%.2f" .BarWidth}}" height="{{barHeight}}"/><line class="threshold" x1="{{printf "%.2f" $x}}" y1="0" x2="{{printf "%.2f" $x}}" y2="{{barHeight}}"/></svg></td>
+</tr>
+{{- end}}
+</tbody>
+</table>
+{{- range .Transfers}}
+<p>{{.Kind}} transfers:</p>
+<ul class="transfers">
+{{- range .Received}}
+<li>{{.Name}}: {{.Votes}}</li>
+{{- end}}
+{{- if .Exhausted}}
+<li>exhausted: {{.Exhausted}}</li>
+{{- end}}
+</ul>
+{{- end}}
+{{- range .Elected}}
+<p class="event">Elected: {{.}}</p>
+{{- end}}
+{{- range .Defeated}}
+<p class="event">Eliminated: {{.}}</p>
+{{- end}}
+</section>
+{{- end}}
+</body>
+</html>
+`))
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/linuxfoundation-it/meek-stv/election"
+)
+
+func infoCmd(args []string, env *env) int {
+	fs := newFlagSet("info", "[ballot file]", "Shows the candidates and ballot statistics of an election.", env)
+	input := addInputFlags(fs)
+
+	positional, err := parseFlags(fs, args)
+	if err != nil {
+		return usageError(err)
+	}
+	if len(positional) > 1 {
+		fs.Usage()
+		return exitUsage
+	}
+
+	e, code, err := input.read(first(positional), env.stderr, env)
+	if err != nil {
+		fmt.Fprintln(env.stderr, err)
+		return code
+	}
+
+	var total, valid election.Weight
+	ranked := 0.0
+	first := make([]election.Weight, e.Candidates)
+	// shared holds the first preferences split among candidates ranked equally first, which aren't exact decimals
+	shared := make([]float64, e.Candidates)
+	for _, b := range e.Ballots {
+		total = total.Add(b.Weight)
+		ranked += b.Weight.Float64() * float64(len(b.Preferences))
+		if group := b.Preferences[:min(len(b.Preferences), b.GroupEnd(0))]; len(group) == 1 {
+			first[group[0]] = first[group[0]].Add(b.Weight)
+		} else {
+			for _, c := range group {
+				shared[c] += b.Weight.Float64() / float64(len(group))
+			}
+		}
+		if !b.IsEmpty() && !b.AllWithdrawn(e.Withdrawn) {
+			valid = valid.Add(b.Weight)
+		}
+	}
+
+	fmt.Fprintf(env.stdout, "%s\n", e.Title)
+	fmt.Fprintf(env.stdout, "seats: %d\n", e.Seats)
+	fmt.Fprintf(env.stdout, "ballots: %s (%d lines)\n", total, len(e.Ballots))
+	fmt.Fprintf(env.stdout, "valid ballots: %s\n", valid)
+	fmt.Fprintf(env.stdout, "empty ballots: %s\n", e.CountEmpty())
+	if total.Sign() > 0 {
+		fmt.Fprintf(env.stdout, "average ranking length: %.02f\n", ranked/total.Float64())
+	}
+	fmt.Fprintln(env.stdout)
+
+	tw := tabwriter.NewWriter(env.stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "#\tcandidate\tfirst preferences\t")
+	for i, name := range e.CandidateNames {
+		if e.Withdrawn[i] {
+			name += " (withdrawn)"
+		}
+		votes := first[i].String()
+		if shared[i] > 0 {
+			votes = strconv.FormatFloat(first[i].Float64()+shared[i], 'f', 2, 64)
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%s\t\n", i+1, name, votes)
+	}
+	if err := tw.Flush(); err != nil {
+		fmt.Fprintln(env.stderr, err)
+		return exitFailure
+	}
+	return exitOK
+}
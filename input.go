@@ -0,0 +1,343 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/linuxfoundation-it/meek-stv/cvr"
+	"github.com/linuxfoundation-it/meek-stv/election"
+	"github.com/linuxfoundation-it/meek-stv/rctab"
+)
+
+// newFlagSet returns the flag set of a command, printing its usage to env.stderr
+func newFlagSet(name, args, summary string, env *env) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(env.stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(env.stderr, "usage: meek-stv %s [flags] %s\n\n%s\n\n", name, args, summary)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// parseFlags parses flags placed anywhere among the arguments, and returns the positional ones
+func parseFlags(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+// usageError reports a flag parsing error with the exit status it deserves
+func usageError(err error) int {
+	if errors.Is(err, flag.ErrHelp) {
+		return exitOK
+	}
+	return exitUsage
+}
+
+// countFlags are the flags of the commands that count an election
+type countFlags struct {
+	seats    *int
+	withdraw *string
+	input    *inputFlags
+}
+
+func addCountFlags(fs *flag.FlagSet) *countFlags {
+	return &countFlags{
+		seats:    fs.Int("seats", 0, "number of seats, overrides the ballot file"),
+		withdraw: fs.String("withdraw", "", "comma separated candidates to withdraw, by number (1-based) or name"),
+		input:    addInputFlags(fs),
+	}
+}
+
+// load reads the ballot file at path, cleans its ballots and applies the seats and withdrawn candidates of the flags.
+// It returns a nil election and the exit status if it fails.
+func (f *countFlags) load(path string, env *env) (*election.Election, int) {
+	e, code, err := f.input.read(path, env.stderr, env)
+	if err != nil {
+		fmt.Fprintln(env.stderr, err)
+		return nil, code
+	}
+
+	withdrawn, err := parseCandidates(*f.withdraw, e)
+	if err != nil {
+		fmt.Fprintln(env.stderr, err)
+		return nil, exitUsage
+	}
+	if err := e.Override(*f.seats, withdrawn); err != nil {
+		fmt.Fprintln(env.stderr, err)
+		return nil, exitUsage
+	}
+	return e, exitOK
+}
+
+// cleanFlags are the ballot cleaning rules of the commands that read ballots
+type cleanFlags struct {
+	overvotes, duplicates, skippedRanks *string
+	maxSkipped                          *int
+}
+
+func addCleanFlags(fs *flag.FlagSet) *cleanFlags {
+	return &cleanFlags{
+		overvotes:    fs.String("overvotes", "keep", "ranks marking several candidates: keep (as equal rankings), skip, exhaust or reject"),
+		duplicates:   fs.String("duplicates", "keep", "candidates ranked again: keep, skip, exhaust or reject"),
+		skippedRanks: fs.String("skipped-ranks", "keep", "blank ranks before marked ones: keep, skip, exhaust or reject"),
+		maxSkipped:   fs.Int("max-skipped", 0, "consecutive skipped ranks allowed before --skipped-ranks applies"),
+	}
+}
+
+// rules returns the cleaning rules of the flags
+func (f *cleanFlags) rules() (election.Rules, error) {
+	var r election.Rules
+	var err error
+	for _, p := range []struct {
+		policy *election.Policy
+		flag   string
+	}{{&r.Overvotes, *f.overvotes}, {&r.Duplicates, *f.duplicates}, {&r.SkippedRanks, *f.skippedRanks}} {
+		if *p.policy, err = election.ParsePolicy(p.flag); err != nil {
+			return r, err
+		}
+	}
+	if *f.maxSkipped < 0 {
+		return r, fmt.Errorf("negative --max-skipped %d", *f.maxSkipped)
+	}
+	r.MaxSkipped = *f.maxSkipped
+	return r, nil
+}
+
+// writeCleanReport writes the number of ballots each cleaning rule changed to w
+func writeCleanReport(w io.Writer, rules election.Rules, report election.CleanReport) {
+	for _, r := range []struct {
+		ballots election.Weight
+		rule    string
+		policy  election.Policy
+	}{
+		{report.Overvotes, "overvotes", rules.Overvotes},
+		{report.Duplicates, "duplicate rankings", rules.Duplicates},
+		{report.SkippedRanks, "skipped ranks", rules.SkippedRanks},
+	} {
+		if r.ballots.Sign() > 0 {
+			fmt.Fprintf(w, "cleaned: %s ballots with %s (%s)\n", r.ballots, r.rule, r.policy)
+		}
+	}
+	if report.Rejected.Sign() > 0 {
+		fmt.Fprintf(w, "cleaned: %s ballots rejected\n", report.Rejected)
+	}
+}
+
+// inputFlags are the flags of the commands that read a ballot file
+type inputFlags struct {
+	format         *string
+	contest        *string
+	ignoreWriteIns *bool
+	firstColumn    *int
+	idColumn       *int
+	aggregate      *bool
+	clean          *cleanFlags
+}
+
+func addInputFlags(fs *flag.FlagSet) *inputFlags {
+	return &inputFlags{
+		format:         fs.String("input-format", "auto", "ballot file format: auto (guessed from the file extension and content), blt, json, cvr (NIST cast vote records) or rctab (RCTab CSV cast vote records)"),
+		contest:        fs.String("contest", "", "ID of the contest to read from cast vote records, if they have several"),
+		ignoreWriteIns: fs.Bool("ignore-write-ins", false, "leave ranks marking unresolved write-ins in cast vote records blank"),
+		firstColumn:    fs.Int("first-vote-column", 1, "column of the first rank in RCTab CSV cast vote records, 1-based"),
+		idColumn:       fs.Int("id-column", 0, "column of the ballot IDs in RCTab CSV cast vote records, 1-based, or 0 for none"),
+		aggregate:      fs.Bool("aggregate", false, "merge identical ballots of BLT files while reading them, to count very large files in little memory"),
+		clean:          addCleanFlags(fs),
+	}
+}
+
+// read reads the ballot file at path and cleans its ballots, writing what cleaning changed to w.
+// It returns the exit status along with the error if it fails.
+func (f *inputFlags) read(path string, w io.Writer, env *env) (*election.Election, int, error) {
+	rules, err := f.clean.rules()
+	if err != nil {
+		return nil, exitUsage, err
+	}
+	opts := readOptions{
+		cvr:       cvr.Options{Contest: *f.contest, Rules: rules, IgnoreWriteIns: *f.ignoreWriteIns},
+		csv:       rctabCSVOptions,
+		aggregate: *f.aggregate,
+	}
+	opts.csv.FirstVoteColumnIndex, opts.csv.IDColumnIndex = *f.firstColumn, *f.idColumn
+	e, report, err := readElection(path, *f.format, opts, env)
+	if err != nil {
+		return nil, exitFailure, err
+	}
+	writeCleanReport(w, rules, report)
+	return e, exitOK, nil
+}
+
+// rctabCSVOptions are the labels RCTab CSV cast vote records are read with
+var rctabCSVOptions = rctab.CSVOptions{
+	OvervoteDelimiter:      "|",
+	OvervoteLabel:          "overvote",
+	UndervoteLabel:         "undervote",
+	UndeclaredWriteInLabel: "UWI",
+}
+
+// readOptions are the settings of the ballot file formats
+type readOptions struct {
+	// cvr reads cast vote records, and holds the rules that clean the ballots of every format
+	cvr cvr.Options
+	// csv reads RCTab CSV cast vote records
+	csv rctab.CSVOptions
+	// aggregate merges identical ballots of BLT files
+	aggregate bool
+}
+
+// readElection reads the ballot file at path, or standard input if path is empty or "-", and cleans its ballots
+// with the rules of opts. format is "blt", "json", "cvr", "rctab", or "auto" to guess it from the file extension
+// and content, telling cast vote records from the JSON ballot format by their top-level keys.
+func readElection(path, format string, opts readOptions, env *env) (*election.Election, election.CleanReport, error) {
+	var in io.Reader = env.stdin
+	if path != "" && path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, election.CleanReport{}, err
+		}
+		defer f.Close()
+		in = f
+	}
+
+	br := bufio.NewReader(in)
+	if format == "auto" {
+		format = detectFormat(path, br)
+	}
+
+	var e *election.Election
+	var err error
+	switch format {
+	case "blt":
+		if opts.aggregate {
+			e, err = election.ParseAggregated(br)
+		} else {
+			e, err = election.Parse(br)
+		}
+		if err == nil {
+			e.Unquote()
+		}
+	case "json":
+		e, err = election.ReadJSON(br)
+	case "cvr":
+		return cvr.Read(br, opts.cvr)
+	case "rctab":
+		csv := opts.csv
+		csv.Rules = opts.cvr.Rules
+		if csv.Title == "" && path != "" && path != "-" {
+			csv.Title = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+		}
+		return rctab.ReadCSV(br, csv)
+	default:
+		err = fmt.Errorf("unknown input format %q", format)
+	}
+	if err != nil {
+		return nil, election.CleanReport{}, err
+	}
+	return e, opts.cvr.Rules.Apply(e), nil
+}
+
+func detectFormat(path string, br *bufio.Reader) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return jsonFormat(br)
+	case ".blt", ".txt":
+		return "blt"
+	case ".csv":
+		return "rctab"
+	}
+	for {
+		b, err := br.Peek(1)
+		if err != nil {
+			return "blt"
+		}
+		switch b[0] {
+		case ' ', '\t', '\r', '\n':
+			br.ReadByte()
+		case '{':
+			return jsonFormat(br)
+		default:
+			return "blt"
+		}
+	}
+}
+
+// cvrKeys are top-level keys of NIST cast vote records, which the JSON ballot format doesn't have
+var cvrKeys = map[string]bool{
+	"@type": true, "Version": true, "CVR": true, "Election": true, "GpUnit": true,
+	"GeneratedDate": true, "ReportGeneratingDeviceIds": true, "ReportingDevice": true,
+}
+
+// jsonFormat tells NIST cast vote records, "cvr", from the JSON ballot format, "json", by the top-level keys
+// of the document found at the start of br, without reading it. It returns "json" when none of them tells.
+func jsonFormat(br *bufio.Reader) string {
+	head, _ := br.Peek(br.Size())
+	dec := json.NewDecoder(bytes.NewReader(head))
+	if t, err := dec.Token(); err != nil || t != json.Delim('{') {
+		return "json"
+	}
+	for dec.More() {
+		t, err := dec.Token()
+		key, ok := t.(string)
+		if err != nil || !ok {
+			break
+		}
+		if cvrKeys[key] {
+			return "cvr"
+		}
+		var value json.RawMessage
+		if err := dec.Decode(&value); err != nil {
+			break
+		}
+	}
+	return "json"
+}
+
+// parseCandidates resolves a comma separated list of candidates, given either
+// by 1-based number as in ballot files or by name, into 0-based indices
+func parseCandidates(list string, e *election.Election) ([]int, error) {
+	var out []int
+	for _, s := range strings.Split(list, ",") {
+		if s = strings.TrimSpace(s); s == "" {
+			continue
+		}
+		if n, err := strconv.Atoi(s); err == nil {
+			if n < 1 || n > e.Candidates {
+				return nil, fmt.Errorf("candidate %d out of range 1-%d", n, e.Candidates)
+			}
+			out = append(out, n-1)
+			continue
+		}
+		idx := -1
+		for i, name := range e.CandidateNames {
+			if name == s {
+				idx = i
+				break
+			}
+		}
+		if idx < 0 {
+			return nil, fmt.Errorf("unknown candidate %q", s)
+		}
+		out = append(out, idx)
+	}
+	return out, nil
+}
@@ -2,35 +2,82 @@ package main
 
 import (
 	"fmt"
+	"io"
 	"os"
-	"sort"
+)
+
+const usage = `meek-stv counts elections with the Meek STV method.
+
+Usage:
+  meek-stv <command> [flags] [ballot file]
+
+Commands:
+  count       count an election and print the results
+  validate    check that a ballot file is well formed
+  convert     convert a ballot file between the OpaVote (BLT) and JSON formats
+  info        show candidates and ballot statistics
+  stats       show first preference and ranking statistics
+  flow        export the vote transfers between rounds as a Sankey diagram
+  provenance  show which ballots back each candidate at the end of the count
+  serve       serve the HTTP JSON API
+  verify      cross-check a count against OpaVote results
+  bootstrap   estimate winner stability by recounting resampled ballots
+  generate    generate a random election from a voter model
 
-	"github.com/linuxfoundation-it/meek-stv/election"
-	"github.com/linuxfoundation-it/meek-stv/meekstv"
+The ballot file is read from standard input when it is omitted or "-".
+Run "meek-stv <command> -h" to show the flags of a command.
+
+Exit status is 0 on success, 1 if the input is invalid or the command fails,
+and 2 on usage errors.
+`
+
+const (
+	exitOK      = 0
+	exitFailure = 1
+	exitUsage   = 2
 )
 
-func main() {
-	f, err := os.Open("testdata/election14.txt")
-	if err != nil {
-		panic(err)
-	}
+// streams of a command invocation, replaced in tests
+type env struct {
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+}
 
-	data := election.Read(f)
-	report := meekstv.Count(data)
+var commands = map[string]func([]string, *env) int{
+	"count":      countCmd,
+	"validate":   validateCmd,
+	"convert":    convertCmd,
+	"info":       infoCmd,
+	"stats":      statsCmd,
+	"flow":       flowCmd,
+	"provenance": provenanceCmd,
+	"serve":      serveCmd,
+	"verify":     verifyCmd,
+	"bootstrap":  bootstrapCmd,
+	"generate":   generateCmd,
+}
 
-	report.Print()
+func main() {
+	os.Exit(run(os.Args[1:], &env{stdin: os.Stdin, stdout: os.Stdout, stderr: os.Stderr}))
+}
 
-	elected := report.Results()
-	sort.Slice(elected, func(i, j int) bool {
-		return elected[i].Votes >= elected[j].Votes
-	})
-	elected = elected[:data.Seats]
+func run(args []string, env *env) int {
+	if len(args) == 0 {
+		fmt.Fprint(env.stderr, usage)
+		return exitUsage
+	}
 
-	fmt.Println("-------------------------")
-	fmt.Printf("Results of %s\n", data.Title)
-	for _, e := range elected {
-		if e.State == meekstv.Elected {
-			fmt.Printf("%s is elected with %.02f votes\n", e.Name, e.Votes)
+	switch name := args[0]; name {
+	case "help", "-h", "-help", "--help":
+		fmt.Fprint(env.stdout, usage)
+		return exitOK
+	default:
+		cmd, ok := commands[name]
+		if !ok {
+			fmt.Fprintf(env.stderr, "unknown command %q\n\n%s", name, usage)
+			return exitUsage
 		}
+		return cmd(args[1:], env)
 	}
 }
@@ -0,0 +1,274 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// runCmd runs the command line with the given standard input
+// and returns its exit status and standard output
+func runCmd(t *testing.T, stdin string, args ...string) (int, string) {
+	t.Helper()
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+	code := run(args, &env{stdin: strings.NewReader(stdin), stdout: stdout, stderr: stderr})
+	if code != exitOK {
+		t.Logf("stderr: %s", stderr)
+	}
+	return code, stdout.String()
+}
+
+func TestCount(t *testing.T) {
+	code, out := runCmd(t, "", "count", "testdata/election13.txt")
+	assert.Equal(t, exitOK, code)
+	assert.Contains(t, out, `"Stephen Rauch" is elected with 9356.00 votes`)
+	assert.Contains(t, out, `"Zoe" is elected with 9208.00 votes`)
+}
+
+func TestCount_MoreSeatsThanCandidates(t *testing.T) {
+	code, out := runCmd(t, "", "count", "testdata/election14.txt", "--seats", "9")
+	assert.Equal(t, exitOK, code)
+	assert.Equal(t, 6, strings.Count(out, "is elected"))
+	assert.Contains(t, out, "3 of 9 seats are unfilled")
+}
+
+func TestCount_Stdin(t *testing.T) {
+	ballots, err := os.ReadFile("testdata/election13.txt")
+	require.NoError(t, err)
+
+	code, out := runCmd(t, string(ballots), "count", "--withdraw", "Zoe,4", "--seats", "1")
+	assert.Equal(t, exitOK, code)
+	assert.Contains(t, out, `"Stephen Rauch" is elected`)
+	assert.NotContains(t, out, `"Zoe" is elected`)
+}
+
+func TestCount_OpaVote(t *testing.T) {
+	code, out := runCmd(t, "", "count", "--format", "opavote", "testdata/election14.txt")
+	assert.Equal(t, exitOK, code)
+	assert.Contains(t, out, `"winners": [`)
+}
+
+func TestCount_JSON(t *testing.T) {
+	code, out := runCmd(t, "", "count", "--format", "json", "testdata/election14.txt")
+	assert.Equal(t, exitOK, code)
+	assert.Contains(t, out, `"schema_version": 1`)
+}
+
+func TestCount_Table(t *testing.T) {
+	code, out := runCmd(t, "", "count", "--format", "table", "--order", "amount", "--precision", "0", "testdata/election13.txt")
+	assert.Equal(t, exitOK, code)
+	assert.Contains(t, out, "Threshold")
+	assert.Contains(t, out, `"Zoe" is elected with 9208 votes`)
+
+	code, _ = runCmd(t, "", "count", "--order", "random", "testdata/election13.txt")
+	assert.Equal(t, exitUsage, code)
+
+	// unknown formats are rejected before the ballots are read
+	code, _ = runCmd(t, "not a ballot file", "count", "--format", "bogus")
+	assert.Equal(t, exitUsage, code)
+}
+
+func TestCount_Vacate(t *testing.T) {
+	code, out := runCmd(t, "", "count", "--vacate", "Zoe", "testdata/election13.txt")
+	assert.Equal(t, exitOK, code)
+	assert.Contains(t, out, `"Stephen Rauch" is elected`)
+	assert.NotContains(t, out, `"Zoe" is elected`)
+	assert.Equal(t, 2, strings.Count(out, "is elected"))
+
+	code, _ = runCmd(t, "", "count", "--vacate", "Zoe", "--winners", "Stephen Rauch", "testdata/election13.txt")
+	assert.Equal(t, exitUsage, code)
+}
+
+func TestCount_Classes(t *testing.T) {
+	ballots := `{"title": "T", "seats": 2, "candidates": ["A", "B", "C"],
+		"classes": [{"name": "APAC", "seats": 1, "candidates": [2]}],
+		"ballots": [{"weight": 5, "preferences": [0]}, {"weight": 4, "preferences": [1]}, {"weight": 1, "preferences": [2]}]}`
+	code, out := runCmd(t, ballots, "count")
+	assert.Equal(t, exitOK, code)
+	assert.Contains(t, out, `"A" is elected with 5.00 votes`+"\n")
+	assert.Contains(t, out, `"C" is elected with 1.00 votes to a seat reserved for APAC`)
+	assert.NotContains(t, out, `"B" is elected`)
+
+	// fewer seats than the classes reserve
+	code, _ = runCmd(t, strings.Replace(ballots, `"seats": 1`, `"seats": 2`, 1), "count", "--seats", "1")
+	assert.Equal(t, exitUsage, code)
+}
+
+func TestCount_CVR(t *testing.T) {
+	code, counted := runCmd(t, "", "count", "--input-format", "cvr", "--contest", "contest-mayor", "testdata/cvr/springfield.json")
+	assert.Equal(t, exitOK, code)
+	assert.Contains(t, counted, `Results of "Mayor"`)
+	assert.Contains(t, counted, `"Bob Brown" is elected`)
+
+	code, out := runCmd(t, "", "provenance", "--input-format", "cvr", "--contest", "contest-mayor", "testdata/cvr/springfield.json")
+	assert.Equal(t, exitOK, code)
+	assert.True(t, strings.HasPrefix(out, "ballot,id,weight,preferences,"))
+	assert.Contains(t, out, "\n10,cvr-011,")
+
+	// cast vote records are told from the JSON ballot format by their keys
+	code, detected := runCmd(t, "", "count", "--contest", "contest-mayor", "testdata/cvr/springfield.json")
+	assert.Equal(t, exitOK, code)
+	assert.Equal(t, counted, detected)
+
+	code, _ = runCmd(t, "", "count", "--input-format", "cvr", "testdata/cvr/springfield.json")
+	assert.Equal(t, exitFailure, code)
+}
+
+func TestCount_RCTab(t *testing.T) {
+	code, out := runCmd(t, "", "count", "--first-vote-column", "3", "--id-column", "1", "--format", "rctab", "testdata/rctab/cvr.csv")
+	assert.Equal(t, exitOK, code)
+	assert.Contains(t, out, `"contest": "cvr"`)
+	assert.Contains(t, out, `"Undeclared Write-ins"`)
+	assert.Contains(t, out, `"totalNumBallots": "10"`)
+
+	code, out = runCmd(t, "", "provenance", "--first-vote-column", "3", "--id-column", "1", "testdata/rctab/cvr.csv")
+	assert.Equal(t, exitOK, code)
+	assert.Contains(t, out, "\n1,b-001,")
+}
+
+func TestCount_Aggregate(t *testing.T) {
+	_, want := runCmd(t, "", "count", "testdata/election13.txt")
+	code, out := runCmd(t, "", "count", "--aggregate", "testdata/election13.txt")
+	assert.Equal(t, exitOK, code)
+	assert.Equal(t, want, out)
+}
+
+func TestVerify(t *testing.T) {
+	code, out := runCmd(t, "", "verify", "testdata/election13.txt", "testdata/election13.json")
+	assert.Equal(t, exitOK, code)
+	assert.Contains(t, out, "the count matches OpaVote in all 3 rounds")
+
+	code, out = runCmd(t, "", "verify", "testdata/election12.txt", "testdata/election13.json")
+	assert.Equal(t, exitFailure, code)
+	assert.Contains(t, out, "differences with OpaVote")
+
+	code, _ = runCmd(t, "", "verify", "testdata/election13.txt")
+	assert.Equal(t, exitUsage, code)
+}
+
+func TestBootstrap(t *testing.T) {
+	code, out := runCmd(t, "", "bootstrap", "--iterations", "20", "--format", "csv", "testdata/election13.txt")
+	assert.Equal(t, exitOK, code)
+	assert.Contains(t, out, "Zoe")
+
+	code, _ = runCmd(t, "", "bootstrap", "--format", "xml", "testdata/election13.txt")
+	assert.Equal(t, exitUsage, code)
+}
+
+func TestGenerate(t *testing.T) {
+	code, out := runCmd(t, "", "generate", "--candidates", "4", "--seats", "2", "--ballots", "50", "--seed", "3")
+	assert.Equal(t, exitOK, code)
+	assert.True(t, strings.HasPrefix(out, "4 2\n"))
+
+	code, count := runCmd(t, out, "count")
+	assert.Equal(t, exitOK, code)
+	assert.Contains(t, count, "is elected")
+
+	code, _ = runCmd(t, "", "generate", "--model", "nope")
+	assert.Equal(t, exitUsage, code)
+
+	code, out = runCmd(t, "", "generate", "--candidates", "4", "--withdraw", "2,4")
+	assert.Equal(t, exitOK, code)
+	assert.True(t, strings.HasPrefix(out, "4 2\n-2 -4\n"), out)
+	code, _ = runCmd(t, "", "generate", "--candidates", "4", "--withdraw", "5")
+	assert.Equal(t, exitUsage, code)
+}
+
+func TestFlow(t *testing.T) {
+	code, out := runCmd(t, "", "flow", "testdata/election13.txt")
+	assert.Equal(t, exitOK, code)
+	assert.Contains(t, out, `"kind": "transfer"`)
+
+	code, out = runCmd(t, "", "flow", "--format", "svg", "--seats", "3", "testdata/election13.txt")
+	assert.Equal(t, exitOK, code)
+	assert.Contains(t, out, "<svg ")
+}
+
+func TestProvenance(t *testing.T) {
+	code, out := runCmd(t, "", "provenance", "--candidate", "Zoe", "testdata/election13.txt")
+	assert.Equal(t, exitOK, code)
+	assert.True(t, strings.HasPrefix(out, "ballot,weight,preferences,votes\n"))
+
+	code, out = runCmd(t, "", "provenance", "testdata/election13.txt")
+	assert.Equal(t, exitOK, code)
+	assert.Contains(t, out, "Stephen Rauch")
+
+	code, _ = runCmd(t, "", "provenance", "--candidate", "Nobody", "testdata/election13.txt")
+	assert.Equal(t, exitUsage, code)
+}
+
+func TestStats(t *testing.T) {
+	code, out := runCmd(t, "", "stats", "--patterns", "3", "testdata/election13.txt")
+	assert.Equal(t, exitOK, code)
+	assert.Contains(t, out, "Most common ballots")
+
+	code, out = runCmd(t, "", "stats", "--format", "json", "--withdraw", "Zoe", "testdata/election13.txt")
+	assert.Equal(t, exitOK, code)
+	assert.Contains(t, out, `"withdrawn": true`)
+}
+
+func TestValidate(t *testing.T) {
+	code, out := runCmd(t, "", "validate", "testdata/election12.txt")
+	assert.Equal(t, exitOK, code)
+	assert.Contains(t, out, "valid: 7 candidates, 2 seats, 259 ballot lines")
+
+	code, out = runCmd(t, "2 1\n1 3 0\n0\n\"A\"\n\"B\"\n\"T\"\n", "validate")
+	assert.Equal(t, exitFailure, code)
+	assert.Contains(t, out, "invalid: line 2: candidate 3 out of range")
+}
+
+func TestValidate_Clean(t *testing.T) {
+	ballots := "3 1\n3 1=2 3 0\n2 2 2 0\n1 3 0\n0\n\"A\"\n\"B\"\n\"C\"\n\"T\"\n"
+	code, out := runCmd(t, ballots, "validate", "--overvotes", "reject", "--duplicates", "skip")
+	assert.Equal(t, exitOK, code)
+	assert.Contains(t, out, "cleaned: 3 ballots with overvotes (reject)")
+	assert.Contains(t, out, "cleaned: 2 ballots with duplicate rankings (skip)")
+	assert.Contains(t, out, "valid: 3 candidates, 1 seats, 2 ballot lines")
+
+	code, out = runCmd(t, ballots, "convert", "--to", "blt", "--overvotes", "exhaust")
+	assert.Equal(t, exitOK, code)
+	assert.Contains(t, out, "\n3 0\n")
+
+	code, _ = runCmd(t, ballots, "count", "--overvotes", "drop")
+	assert.Equal(t, exitUsage, code)
+}
+
+func TestConvert(t *testing.T) {
+	want, err := os.ReadFile("testdata/election12.txt")
+	require.NoError(t, err)
+
+	code, json := runCmd(t, "", "convert", "--to", "json", "testdata/election12.txt")
+	require.Equal(t, exitOK, code)
+
+	code, blt := runCmd(t, json, "convert", "--to", "blt")
+	require.Equal(t, exitOK, code)
+	assert.Equal(t, string(want), blt)
+}
+
+func TestInfo(t *testing.T) {
+	code, out := runCmd(t, "", "info", "testdata/election12.txt")
+	assert.Equal(t, exitOK, code)
+	assert.Contains(t, out, "empty ballots: 388")
+	assert.Contains(t, out, "Yvette (withdrawn)")
+}
+
+func TestUsage(t *testing.T) {
+	code, _ := runCmd(t, "")
+	assert.Equal(t, exitUsage, code)
+
+	code, _ = runCmd(t, "", "recount")
+	assert.Equal(t, exitUsage, code)
+
+	code, _ = runCmd(t, "", "count", "--seats", "-1", "testdata/election12.txt")
+	assert.Equal(t, exitUsage, code)
+
+	code, _ = runCmd(t, "", "count", "-h")
+	assert.Equal(t, exitOK, code)
+
+	code, _ = runCmd(t, "", "count", "testdata/missing.txt")
+	assert.Equal(t, exitFailure, code)
+}
@@ -1,5 +1,7 @@
 package meekstv
 
+import "fmt"
+
 type CandidateState int
 
 const (
@@ -9,6 +11,38 @@ const (
 	Elected
 )
 
+var stateNames = [...]string{
+	Hopeful:   "hopeful",
+	Withdrawn: "withdrawn",
+	Defeated:  "defeated",
+	Elected:   "elected",
+}
+
+func (s CandidateState) String() string {
+	if s < 0 || int(s) >= len(stateNames) {
+		return fmt.Sprintf("CandidateState(%d)", int(s))
+	}
+	return stateNames[s]
+}
+
+// MarshalText encodes the state by name, so that JSON documents don't depend on the order of the constants.
+func (s CandidateState) MarshalText() ([]byte, error) {
+	if s < 0 || int(s) >= len(stateNames) {
+		return nil, fmt.Errorf("invalid candidate state %d", int(s))
+	}
+	return []byte(stateNames[s]), nil
+}
+
+func (s *CandidateState) UnmarshalText(text []byte) error {
+	for i, name := range stateNames {
+		if name == string(text) {
+			*s = CandidateState(i)
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid candidate state %q", text)
+}
+
 type Candidate struct {
 	Index      int
 	Name       string
@@ -16,6 +50,12 @@ type Candidate struct {
 	KeepFactor float64
 	Votes      float64
 	Surplus    float64
+	// Class is the seat class of the seat an elected candidate fills, empty for an open seat
+	Class string
+
+	// keep and votes are the exact KeepFactor and Votes the count runs on
+	keep  keepFactor
+	votes votes
 }
 
 type Candidates []*Candidate
@@ -23,6 +63,7 @@ type Candidates []*Candidate
 func (cs Candidates) resetVotes() {
 	for _, c := range cs {
 		c.Votes = 0.0
+		c.votes = votes{}
 	}
 }
 
@@ -36,10 +77,10 @@ func (cs Candidates) countState(state CandidateState) int {
 	return n
 }
 
-func (cs Candidates) countVotes() float64 {
-	n := 0.0
+func (cs Candidates) countVotes() votes {
+	var n votes
 	for _, c := range cs {
-		n += c.Votes
+		n = n.add(c.votes)
 	}
 	return n
 }
@@ -0,0 +1,97 @@
+package meekstv
+
+import "github.com/linuxfoundation-it/meek-stv/election"
+
+// seatLedger keeps track of the seats left during a count, open or reserved for a seat class.
+//
+// Reserved seats are filled the Meek way: candidates are elected when they reach the quota,
+// in order of votes, provided a seat is left for them, either reserved for their class or open.
+// Those with no seat left are defeated, and their votes transferred. A hopeful candidate is never
+// defeated for being the lowest when its class has as many reserved seats left as hopeful candidates.
+type seatLedger struct {
+	classes []election.SeatClass
+	// class of each candidate, by index, or -1 for none
+	classOf  []int
+	open     int
+	reserved []int
+}
+
+func newSeatLedger(e *election.Election) *seatLedger {
+	l := &seatLedger{
+		classes:  e.Classes,
+		classOf:  make([]int, e.Candidates),
+		open:     e.OpenSeats(),
+		reserved: make([]int, len(e.Classes)),
+	}
+	for i := range l.classOf {
+		l.classOf[i] = -1
+	}
+	for k, class := range e.Classes {
+		l.reserved[k] = class.Seats
+		for _, c := range class.Candidates {
+			l.classOf[c] = k
+		}
+	}
+	return l
+}
+
+// seat returns the class of the seat candidate c would fill, or -1 for an open seat,
+// and false if no seat is left for c
+func (l *seatLedger) seat(c int) (int, bool) {
+	if k := l.classOf[c]; k >= 0 && l.reserved[k] > 0 {
+		return k, true
+	}
+	return -1, l.open > 0
+}
+
+// fill gives a seat to candidate c, and returns the name of its class, or "" for an open seat
+func (l *seatLedger) fill(c int) string {
+	k, _ := l.seat(c)
+	if k < 0 {
+		l.open--
+		return ""
+	}
+	l.reserved[k]--
+	return l.classes[k].Name
+}
+
+// reserves tells whether candidate c would fill a seat reserved for its class
+func (l *seatLedger) reserves(c int) bool {
+	k, ok := l.seat(c)
+	return ok && k >= 0
+}
+
+// protected tells whether candidate c must be elected for the seats reserved for its class to be filled
+func (l *seatLedger) protected(c int, cs Candidates) bool {
+	k := l.classOf[c]
+	if k < 0 || l.reserved[k] == 0 {
+		return false
+	}
+	hopeful := 0
+	for _, o := range cs {
+		if o.State == Hopeful && l.classOf[o.Index] == k {
+			hopeful++
+		}
+	}
+	return hopeful <= l.reserved[k]
+}
+
+// fits tells whether every hopeful candidate can still be given a seat
+func (l *seatLedger) fits(cs Candidates) bool {
+	hopeful := make([]int, len(l.classes))
+	overflow := 0
+	for _, c := range cs {
+		if c.State != Hopeful {
+			continue
+		}
+		if k := l.classOf[c.Index]; k >= 0 {
+			hopeful[k]++
+		} else {
+			overflow++
+		}
+	}
+	for k, h := range hopeful {
+		overflow += max(h-l.reserved[k], 0)
+	}
+	return overflow <= l.open
+}
@@ -0,0 +1,103 @@
+package meekstv
+
+import (
+	"testing"
+
+	"github.com/linuxfoundation-it/meek-stv/election"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func classElection(weights ...int) *election.Election {
+	e := &election.Election{
+		Title:          "Seat classes",
+		Candidates:     5,
+		Seats:          3,
+		CandidateNames: []string{"A", "B", "C", "D", "E"},
+		Classes:        []election.SeatClass{{Name: "APAC", Seats: 1, Candidates: []int{3, 4}}},
+	}
+	for c, w := range weights {
+		e.Ballots = append(e.Ballots, election.Ballot{Weight: election.NewWeight(int64(w)), Preferences: []int{c}})
+	}
+	return e
+}
+
+func classes(l *Log) map[int]string {
+	out := make(map[int]string)
+	for _, c := range l.Results() {
+		if c.State == Elected {
+			out[c.Index] = c.Class
+		}
+	}
+	return out
+}
+
+func TestCount_ReservedSeat(t *testing.T) {
+	e := classElection(40, 35, 30, 10, 8)
+	e.Ballots[4].Preferences = []int{4, 3}
+
+	report := Count(e)
+	assert.Equal(t, map[int]string{0: "", 1: "", 3: "APAC"}, classes(&report))
+
+	// C reaches no quota, but the open seats are gone after the first round
+	first := report.Round(0)
+	assert.Equal(t, []int{0, 1}, indices(first.Elected))
+	assert.Equal(t, []int{2}, indices(first.Defeated))
+	checkTransferMatrix(t, &report)
+
+	e.Classes = nil
+	report = Count(e)
+	assert.Equal(t, map[int]string{0: "", 1: "", 2: ""}, classes(&report))
+}
+
+func TestCount_ReservedSeatReachedQuota(t *testing.T) {
+	report := Count(classElection(40, 35, 30, 45, 8))
+	assert.Equal(t, map[int]string{0: "", 1: "", 3: "APAC"}, classes(&report))
+	assert.Equal(t, "APAC", report.Round(0).CandidateSnapshot[3].Class)
+}
+
+func TestCount_ReservedSeatProtected(t *testing.T) {
+	e := classElection(40, 35, 30, 1)
+	e.Classes[0].Candidates = []int{3}
+	e.Withdrawn = map[int]bool{4: true}
+
+	report := Count(e)
+	assert.Equal(t, map[int]string{0: "", 1: "", 3: "APAC"}, classes(&report))
+	for i := 0; i < report.NumRounds(); i++ {
+		assert.NotContains(t, indices(report.Round(i).Defeated), 3, "round %d", i)
+	}
+	assert.Equal(t, []int{2}, indices(report.Round(0).Defeated))
+}
+
+func TestCount_NothingToDefeat(t *testing.T) {
+	// classes reserving more seats than the election has, which Count doesn't check, protect every hopeful candidate
+	e := classElection(5, 4, 3)
+	e.Candidates, e.Seats, e.CandidateNames = 3, 1, e.CandidateNames[:3]
+	e.Classes = []election.SeatClass{{Name: "APAC", Seats: 2, Candidates: []int{1, 2}}}
+
+	report := Count(e)
+	for i := 0; i < report.NumRounds(); i++ {
+		for _, c := range report.Round(i).Defeated {
+			assert.NotEmpty(t, c.Name, "round %d", i)
+		}
+	}
+	assert.Len(t, report.Winners(), 1)
+}
+
+func TestSeatLedger(t *testing.T) {
+	e := classElection()
+	l := newSeatLedger(e)
+	cs := newCandidates(e)
+	require.Equal(t, 2, l.open)
+
+	assert.True(t, l.reserves(3))
+	assert.False(t, l.reserves(0))
+	assert.False(t, l.protected(3, cs))
+	assert.False(t, l.fits(cs))
+
+	assert.Equal(t, "APAC", l.fill(3))
+	assert.Equal(t, "", l.fill(4))
+	assert.Equal(t, "", l.fill(0))
+	_, ok := l.seat(1)
+	assert.False(t, ok)
+}
@@ -0,0 +1,61 @@
+package meekstv
+
+import (
+	"fmt"
+	"maps"
+
+	"github.com/linuxfoundation-it/meek-stv/election"
+)
+
+// Countback fills the seats of vacating winners by recounting the original ballots of e.
+// winners are the candidates elected by the original count, and vacating those of them leaving their seat.
+//
+// The vacating winners are withdrawn and the other winners are locked as elected: they are elected
+// in the first round whatever their votes, and their surplus flows like that of any elected candidate.
+// The count then fills as many seats as there are vacating winners.
+func Countback(e *election.Election, winners, vacating []int, opts ...Option) (Log, error) {
+	isWinner := make(map[int]bool, len(winners))
+	for _, c := range winners {
+		if c < 0 || c >= e.Candidates {
+			return Log{}, fmt.Errorf("winner %d out of range 0-%d", c, e.Candidates-1)
+		}
+		if e.Withdrawn[c] {
+			return Log{}, fmt.Errorf("winner %q is withdrawn", e.CandidateNames[c])
+		}
+		if isWinner[c] {
+			return Log{}, fmt.Errorf("winner %q is listed twice", e.CandidateNames[c])
+		}
+		isWinner[c] = true
+	}
+	if len(vacating) == 0 {
+		return Log{}, fmt.Errorf("no vacating winner")
+	}
+
+	recount := *e
+	recount.Seats = len(winners)
+	recount.Withdrawn = maps.Clone(e.Withdrawn)
+	if recount.Withdrawn == nil {
+		recount.Withdrawn = make(map[int]bool)
+	}
+	for _, c := range vacating {
+		if c < 0 || c >= e.Candidates || !isWinner[c] {
+			return Log{}, fmt.Errorf("vacating candidate %d is not a winner", c)
+		}
+		if recount.Withdrawn[c] {
+			return Log{}, fmt.Errorf("vacating winner %q is listed twice", e.CandidateNames[c])
+		}
+		recount.Withdrawn[c] = true
+	}
+
+	if err := recount.ValidateClasses(); err != nil {
+		return Log{}, err
+	}
+
+	cs := newCandidates(&recount)
+	for _, c := range winners {
+		if !recount.Withdrawn[c] {
+			cs[c].State = Elected
+		}
+	}
+	return count(&recount, cs, newConfig(opts)), nil
+}
@@ -0,0 +1,119 @@
+package meekstv
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/linuxfoundation-it/meek-stv/election"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCountback(t *testing.T) {
+	files, err := filepath.Glob(filepath.Join("..", "testdata", "*.txt"))
+	require.NoError(t, err)
+
+	for _, txt := range files {
+		t.Run(strings.TrimSuffix(filepath.Base(txt), ".txt"), func(t *testing.T) {
+			e := readElectionTxt(t, txt)
+			original := Count(e)
+			winners := original.Winners()
+			vacating := winners[0]
+
+			report, err := Countback(e, winners, []int{vacating})
+			require.NoError(t, err)
+
+			results := report.Results()
+			assert.NotEqual(t, Elected, results[vacating].State)
+			for _, c := range winners[1:] {
+				assert.Equal(t, Elected, results[c].State, "locked winner %d", c)
+				assert.Contains(t, report.Round(0).Elected, report.Round(0).CandidateSnapshot[c])
+			}
+			assert.Len(t, report.Winners(), len(winners))
+			checkTransferMatrix(t, &report)
+
+			// the original ballots are left alone
+			assert.False(t, e.Withdrawn[vacating])
+		})
+	}
+}
+
+func TestCountback_LockedBelowQuota(t *testing.T) {
+	// A and B win; B's ballots move on to D when B resigns, D then tops A and C,
+	// yet A keeps its seat with less than a quota
+	e := &election.Election{
+		Title:          "Countback",
+		Candidates:     4,
+		Seats:          2,
+		CandidateNames: []string{"A", "B", "C", "D"},
+		Ballots: []election.Ballot{
+			{Weight: election.NewWeight(30), Preferences: []int{0, 2}},
+			{Weight: election.NewWeight(25), Preferences: []int{1, 3}},
+			{Weight: election.NewWeight(20), Preferences: []int{2}},
+			{Weight: election.NewWeight(15), Preferences: []int{3}},
+			{Weight: election.NewWeight(10), Preferences: []int{1, 2}},
+		},
+	}
+	original := Count(e)
+	require.Equal(t, []int{0, 1}, original.Winners())
+
+	report, err := Countback(e, []int{0, 1}, []int{1})
+	require.NoError(t, err)
+	assert.Equal(t, []int{0, 3}, report.Winners())
+
+	first := report.Round(0)
+	assert.Less(t, first.VotesOf(0), first.Threshold)
+	for i := 0; i < report.NumRounds(); i++ {
+		assert.Equal(t, 1.0, report.Round(i).CandidateSnapshot[0].KeepFactor)
+	}
+}
+
+func TestCountback_SurplusFlows(t *testing.T) {
+	// A is locked with a surplus that decides between C and D
+	e := &election.Election{
+		Title:          "Countback surplus",
+		Candidates:     4,
+		Seats:          2,
+		CandidateNames: []string{"A", "B", "C", "D"},
+		Ballots: []election.Ballot{
+			{Weight: election.NewWeight(50), Preferences: []int{0, 3}},
+			{Weight: election.NewWeight(30), Preferences: []int{1, 2}},
+			{Weight: election.NewWeight(4), Preferences: []int{2}},
+			{Weight: election.NewWeight(20), Preferences: []int{3}},
+		},
+	}
+
+	report, err := Countback(e, []int{0, 1}, []int{1})
+	require.NoError(t, err)
+	assert.Equal(t, []int{0, 3}, report.Winners())
+
+	require.Greater(t, report.NumRounds(), 1)
+	assert.Less(t, report.Round(0).CandidateSnapshot[0].KeepFactor, 1.0)
+	assert.Greater(t, report.Round(1).SurplusReceived[3], 0.0)
+	checkTransferMatrix(t, &report)
+}
+
+func TestCountback_Invalid(t *testing.T) {
+	e := &election.Election{
+		Title:          "Countback",
+		Candidates:     3,
+		Seats:          2,
+		Withdrawn:      map[int]bool{2: true},
+		CandidateNames: []string{"A", "B", "C"},
+	}
+	for _, tc := range []struct {
+		name              string
+		winners, vacating []int
+	}{
+		{"no vacating", []int{0, 1}, nil},
+		{"not a winner", []int{0}, []int{1}},
+		{"out of range", []int{0, 3}, []int{0}},
+		{"withdrawn winner", []int{0, 2}, []int{0}},
+		{"duplicate winner", []int{0, 0}, []int{0}},
+		{"duplicate vacating", []int{0, 1}, []int{1, 1}},
+	} {
+		_, err := Countback(e, tc.winners, tc.vacating)
+		assert.Error(t, err, tc.name)
+	}
+}
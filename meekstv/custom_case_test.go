@@ -27,7 +27,7 @@ func TestCustomCase_EliminationRecipients(t *testing.T) {
 	}
 
 	add := func(weight int, prefs []int) {
-		params.Ballots = append(params.Ballots, election.Ballot{Weight: weight, Preferences: prefs})
+		params.Ballots = append(params.Ballots, election.Ballot{Weight: election.NewWeight(int64(weight)), Preferences: prefs})
 	}
 
 	// 9 ballots, weight 1 each, zero-based indices as provided
@@ -0,0 +1,124 @@
+package meekstv
+
+import (
+	"math/rand/v2"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/linuxfoundation-it/meek-stv/election"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCount_EqualRankings(t *testing.T) {
+	// A and B share the ballots ranking them equally; when B is defeated, A keeps them whole
+	e := &election.Election{
+		Title:          "Equal rankings",
+		Candidates:     3,
+		Seats:          1,
+		CandidateNames: []string{"A", "B", "C"},
+		Ballots: []election.Ballot{
+			{Weight: election.NewWeight(8), Preferences: []int{0, 1}, Equal: []bool{false, true}},
+			{Weight: election.NewWeight(9), Preferences: []int{2}},
+			{Weight: election.NewWeight(2), Preferences: []int{0}},
+		},
+	}
+	report := Count(e, WithProvenance())
+	assert.Equal(t, []int{0}, report.Winners())
+
+	first := report.Round(0)
+	assert.Equal(t, 6.0, first.VotesOf(0))
+	assert.Equal(t, 4.0, first.VotesOf(1))
+	assert.Equal(t, []int{1}, indices(first.Defeated))
+
+	second := report.Round(1)
+	assert.Equal(t, 10.0, second.VotesOf(0))
+	assert.Equal(t, 4.0, second.Transfers.At(1, 0))
+	checkTransferMatrix(t, &report)
+
+	p := report.Provenance()
+	assert.Equal(t, "A = B", p.preferences(p.Ballots[0].Preferences, p.Ballots[0].Equal))
+	// A is backed by its 10 votes of the final round, 8 of them from the ballots ranking it equal to B
+	assert.Equal(t, 8.0, p.Ballots[0].Votes[0])
+	assert.Equal(t, 10.0, p.Total(0))
+}
+
+func TestCount_EqualRankingsSurplus(t *testing.T) {
+	// A's surplus moves on past B, ranked equal to it, to C
+	e := &election.Election{
+		Title:          "Equal rankings surplus",
+		Candidates:     4,
+		Seats:          2,
+		CandidateNames: []string{"A", "B", "C", "D"},
+		Ballots: []election.Ballot{
+			{Weight: election.NewWeight(60), Preferences: []int{0, 1, 2}, Equal: []bool{false, true, false}},
+			{Weight: election.NewWeight(20), Preferences: []int{1}},
+			{Weight: election.NewWeight(25), Preferences: []int{2}},
+			{Weight: election.NewWeight(24), Preferences: []int{3}},
+		},
+	}
+	report := Count(e)
+	assert.ElementsMatch(t, []int{0, 1}, report.Winners())
+	checkTransferMatrix(t, &report)
+}
+
+func TestTransferMatrix_EqualRankingsRounding(t *testing.T) {
+	// 5 billionths ranking A, B and C equally give each a billionth, and each of A and B 2 once C is
+	// defeated: they gain the billionth that C released and the one that moved on from the ballot
+	tiny, err := election.ParseWeight("0.000000005")
+	require.NoError(t, err)
+	e := &election.Election{
+		Title:          "Rounding",
+		Candidates:     4,
+		Seats:          1,
+		CandidateNames: []string{"A", "B", "C", "D"},
+		Ballots: []election.Ballot{
+			{Weight: tiny, Preferences: []int{0, 1, 2}, Equal: []bool{false, true, true}},
+			{Weight: election.NewWeight(7), Preferences: []int{3}},
+			{Weight: election.NewWeight(4), Preferences: []int{0}},
+			{Weight: election.NewWeight(3), Preferences: []int{1}},
+			{Weight: election.NewWeight(1), Preferences: []int{2}},
+		},
+	}
+	report := Count(e)
+	require.Equal(t, []int{2}, indices(report.Round(0).Defeated))
+	checkTransferMatrix(t, &report)
+
+	m := report.Round(1).Transfers
+	assert.Equal(t, votes{n: 1}, m.votes[2][0])
+	assert.Equal(t, votes{n: 1}, m.votes[2][1])
+	// C's own vote exhausts, less the billionth that no longer does
+	assert.Equal(t, votes{n: 1e9 - 1}, m.votes[2][m.Exhausted()])
+}
+
+func TestCount_EqualRankingsReconcile(t *testing.T) {
+	files, err := filepath.Glob(filepath.Join("..", "testdata", "*.txt"))
+	require.NoError(t, err)
+
+	for _, txt := range files {
+		t.Run(strings.TrimSuffix(filepath.Base(txt), ".txt"), func(t *testing.T) {
+			e := readElectionTxt(t, txt)
+			// rank some consecutive preferences equally
+			r := rand.New(rand.NewPCG(1, 0))
+			for i, b := range e.Ballots {
+				equal := make([]bool, len(b.Preferences))
+				for k := 1; k < len(equal); k++ {
+					equal[k] = r.IntN(3) == 0
+				}
+				e.Ballots[i].Equal = equal
+			}
+
+			report := Count(e, WithProvenance())
+			checkTransferMatrix(t, &report)
+			p := report.Provenance()
+			for c, r := range report.Results() {
+				var total votes
+				for _, b := range p.Ballots {
+					total = total.add(b.votes[c])
+				}
+				assert.Zero(t, total.cmp(r.votes), "candidate %d", c)
+			}
+		})
+	}
+}
@@ -0,0 +1,51 @@
+package meekstv
+
+import "github.com/linuxfoundation-it/meek-stv/election"
+
+// ExhaustedBreakdown splits the exhausted votes of a round by cause.
+// OutOfPreferences and OnlyDefeated add up to LogEntry.Exhausted; Empty ballots
+// are left out of it, as Election.CountEmpty does.
+type ExhaustedBreakdown struct {
+	// OutOfPreferences is the weight left over after the last preference of ballots
+	// that still rank a hopeful or elected candidate
+	OutOfPreferences ExhaustedCause `json:"out_of_preferences"`
+	// OnlyDefeated is the weight of ballots that only rank defeated or withdrawn candidates,
+	// at least one of them defeated
+	OnlyDefeated ExhaustedCause `json:"only_defeated"`
+	// Empty is the weight of blank ballots and of ballots that only rank withdrawn candidates
+	Empty ExhaustedCause `json:"empty"`
+	// Rounding is the weight of the valid ballots that is neither counted for a candidate
+	// nor exhausted, because of rounding errors. Votes are counted in fixed point without
+	// losing any weight, so that it is 0.
+	Rounding float64 `json:"rounding"`
+}
+
+// ExhaustedCause is the exhausted weight of a cause, and the full weight of the ballots
+// that exhausted all or part of it because of it: their number when every ballot weighs 1.
+type ExhaustedCause struct {
+	Weight  float64 `json:"weight"`
+	Ballots float64 `json:"ballots"`
+}
+
+func (b *ExhaustedBreakdown) add(bl election.Ballot, w float64, active bool, withdrawn map[int]bool) {
+	cause := &b.OutOfPreferences
+	switch {
+	case bl.IsEmpty() || bl.AllWithdrawn(withdrawn):
+		cause = &b.Empty
+	case !active:
+		cause = &b.OnlyDefeated
+	}
+	cause.Weight += w
+	cause.Ballots += bl.Weight.Float64()
+}
+
+// validWeight returns the weight of the ballots that aren't empty
+func validWeight(e *election.Election) election.Weight {
+	var sum election.Weight
+	for _, b := range e.Ballots {
+		if !b.IsEmpty() && !b.AllWithdrawn(e.Withdrawn) {
+			sum = sum.Add(b.Weight)
+		}
+	}
+	return sum
+}
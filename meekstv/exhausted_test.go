@@ -0,0 +1,78 @@
+package meekstv_test
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/linuxfoundation-it/meek-stv/election"
+	"github.com/linuxfoundation-it/meek-stv/meekstv"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExhaustedBreakdown(t *testing.T) {
+	files, err := filepath.Glob(filepath.Join(testdata, "*.txt"))
+	require.NoError(t, err)
+
+	for _, path := range files {
+		t.Run(strings.TrimSuffix(filepath.Base(path), ".txt"), func(t *testing.T) {
+			e := readBallots(t, path)
+			report := meekstv.Count(e)
+
+			for i := 0; i < report.NumRounds(); i++ {
+				entry := report.Round(i)
+				b := entry.ExhaustedBreakdown
+				assert.InDelta(t, entry.Exhausted, b.OutOfPreferences.Weight+b.OnlyDefeated.Weight, 1e-6, "round %d", entry.Round)
+				assert.Equal(t, e.CountEmpty().Float64(), b.Empty.Weight)
+				assert.Equal(t, e.CountEmpty().Float64(), b.Empty.Ballots)
+				assert.InDelta(t, 0, b.Rounding, 1e-6)
+				if i == 0 {
+					assert.Zero(t, b.OnlyDefeated.Ballots)
+				}
+			}
+		})
+	}
+}
+
+func TestExhaustedBreakdown_Causes(t *testing.T) {
+	e := &election.Election{
+		Title:          "Exhausted",
+		Candidates:     5,
+		Seats:          2,
+		Withdrawn:      map[int]bool{3: true},
+		CandidateNames: []string{"A", "B", "C", "D", "E"},
+		Ballots: []election.Ballot{
+			{Weight: election.NewWeight(10), Preferences: []int{0}},
+			{Weight: election.NewWeight(5), Preferences: []int{1, 0}},
+			{Weight: election.NewWeight(4), Preferences: []int{4}},
+			{Weight: election.NewWeight(1), Preferences: []int{2}},
+			{Weight: election.NewWeight(2), Preferences: []int{2, 3}},
+			{Weight: election.NewWeight(3), Preferences: []int{}},
+			{Weight: election.NewWeight(1), Preferences: []int{3}},
+		},
+	}
+	report := meekstv.Count(e)
+	require.GreaterOrEqual(t, report.NumRounds(), 2)
+
+	// A is elected in the first round, then C is defeated
+	first := report.Round(0)
+	require.Len(t, first.Elected, 1)
+	assert.Equal(t, 0, first.Elected[0].Index)
+	require.Len(t, first.Defeated, 0)
+
+	second := report.Round(1)
+	b := second.ExhaustedBreakdown
+	// A's surplus on the ten ballots ranking only A runs out of preferences
+	assert.Equal(t, 10.0, b.OutOfPreferences.Ballots)
+	assert.Greater(t, b.OutOfPreferences.Weight, 0.0)
+	assert.Equal(t, meekstv.ExhaustedCause{Weight: 4, Ballots: 4}, b.Empty)
+
+	require.Len(t, second.Defeated, 1)
+	assert.Equal(t, 2, second.Defeated[0].Index)
+
+	// the three ballots ranking C, then maybe withdrawn D, only rank defeated or withdrawn candidates
+	third := report.Round(2)
+	assert.Equal(t, meekstv.ExhaustedCause{Weight: 3, Ballots: 3}, third.ExhaustedBreakdown.OnlyDefeated)
+	assert.Equal(t, 10.0, third.ExhaustedBreakdown.OutOfPreferences.Ballots)
+}
@@ -0,0 +1,182 @@
+package meekstv
+
+import (
+	"math"
+	"math/big"
+	"math/bits"
+
+	"github.com/linuxfoundation-it/meek-stv/election"
+)
+
+// The count runs in fixed point, as the Meek rules do: keep factors have 9 decimal places,
+// and votes as many as the ballot weights need, and at least 9, so that ballots are counted exactly
+// whatever their weights and their total. The float64 fields of candidates and log entries
+// are converted from these for reports.
+
+// keepFactor is a keep factor in billionths, from 0 to keepOne
+type keepFactor int64
+
+const keepOne keepFactor = 1e9
+
+func (kf keepFactor) Float64() float64 {
+	return float64(kf) / float64(keepOne)
+}
+
+// update returns kf * q / v, rounded up: the keep factor of a winner with v votes that keeps the quota q
+func (kf keepFactor) update(q, v votes) keepFactor {
+	if q.b == nil && v.b == nil && q.n >= 0 && v.n > q.n {
+		hi, lo := bits.Mul64(uint64(kf), uint64(q.n))
+		n, rem := bits.Div64(hi, lo, uint64(v.n))
+		if rem > 0 {
+			n++
+		}
+		return keepFactor(n)
+	}
+	n := new(big.Int).Mul(big.NewInt(int64(kf)), q.big())
+	return keepFactor(quoUp(n, v.big()).Int64())
+}
+
+// votes is a number of votes in units of the scale of the count. n holds numbers that
+// fit an int64, b all others, so that equal numbers have the same representation.
+type votes struct {
+	n int64
+	b *big.Int
+}
+
+func newVotes(b *big.Int) votes {
+	if b.IsInt64() {
+		return votes{n: b.Int64()}
+	}
+	return votes{b: b}
+}
+
+func (v votes) big() *big.Int {
+	if v.b != nil {
+		return v.b
+	}
+	return big.NewInt(v.n)
+}
+
+func (v votes) add(o votes) votes {
+	if v.b == nil && o.b == nil {
+		if sum := v.n + o.n; (sum > v.n) == (o.n > 0) {
+			return votes{n: sum}
+		}
+	}
+	return newVotes(new(big.Int).Add(v.big(), o.big()))
+}
+
+func (v votes) sub(o votes) votes {
+	if v.b == nil && o.b == nil {
+		if diff := v.n - o.n; (diff < v.n) == (o.n > 0) {
+			return votes{n: diff}
+		}
+	}
+	return newVotes(new(big.Int).Sub(v.big(), o.big()))
+}
+
+func (v votes) neg() votes {
+	return votes{}.sub(v)
+}
+
+func (v votes) cmp(o votes) int {
+	if v.b == nil && o.b == nil {
+		switch {
+		case v.n < o.n:
+			return -1
+		case v.n > o.n:
+			return 1
+		}
+		return 0
+	}
+	return v.big().Cmp(o.big())
+}
+
+func (v votes) sign() int {
+	return v.cmp(votes{})
+}
+
+// keep returns the share kf of the non-negative v, rounded up, or down when up is false
+func (v votes) keep(kf keepFactor, up bool) votes {
+	if v.b == nil && v.n >= 0 {
+		hi, lo := bits.Mul64(uint64(v.n), uint64(kf))
+		n, rem := bits.Div64(hi, lo, uint64(keepOne))
+		if up && rem > 0 {
+			n++
+		}
+		return votes{n: int64(n)}
+	}
+	n := new(big.Int).Mul(v.big(), big.NewInt(int64(kf)))
+	if up {
+		return newVotes(quoUp(n, big.NewInt(int64(keepOne))))
+	}
+	return newVotes(n.Quo(n, big.NewInt(int64(keepOne))))
+}
+
+// div returns the non-negative v divided by d, rounded up, or down when up is false
+func (v votes) div(d int64, up bool) votes {
+	if v.b == nil && v.n >= 0 {
+		n := v.n / d
+		if up && v.n%d > 0 {
+			n++
+		}
+		return votes{n: n}
+	}
+	if up {
+		return newVotes(quoUp(new(big.Int).Set(v.big()), big.NewInt(d)))
+	}
+	return newVotes(new(big.Int).Quo(v.big(), big.NewInt(d)))
+}
+
+// mulDiv returns v * n / d, rounded down, for non-negative v and n and positive d
+func (v votes) mulDiv(n, d votes) votes {
+	x := new(big.Int).Mul(v.big(), n.big())
+	return newVotes(x.Quo(x, d.big()))
+}
+
+// quoUp sets n to n / d rounded up, for non-negative n and positive d, and returns it
+func quoUp(n, d *big.Int) *big.Int {
+	m := new(big.Int)
+	if n.QuoRem(n, d, m); m.Sign() > 0 {
+		n.Add(n, big.NewInt(1))
+	}
+	return n
+}
+
+// scale converts ballot weights to votes, counted in units of 10^-decimals
+type scale struct {
+	decimals int
+	unit     *big.Int
+}
+
+// newScale returns the scale of 9 decimals, or of more if the weight of a ballot of e needs them
+func newScale(e *election.Election) scale {
+	s := scale{decimals: 9}
+	for _, b := range e.Ballots {
+		s.decimals = max(s.decimals, b.Weight.Decimals())
+	}
+	s.unit = new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(s.decimals)), nil)
+	return s
+}
+
+func (s scale) votes(w election.Weight) votes {
+	return newVotes(w.Scale(s.decimals))
+}
+
+// weights returns the weight of every ballot of e in votes
+func (s scale) weights(e *election.Election) []votes {
+	out := make([]votes, len(e.Ballots))
+	for i, b := range e.Ballots {
+		out[i] = s.votes(b.Weight)
+	}
+	return out
+}
+
+// float64 returns the nearest float64 to v
+func (s scale) float64(v votes) float64 {
+	if v.b == nil && s.decimals <= 22 && v.n < 1<<53 && v.n > -1<<53 {
+		return float64(v.n) / math.Pow10(s.decimals)
+	}
+	f, _ := new(big.Rat).SetFrac(v.big(), s.unit).Float64()
+	return f
+}
@@ -0,0 +1,57 @@
+package meekstv
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/linuxfoundation-it/meek-stv/election"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVotes_Keep(t *testing.T) {
+	third := keepFactor(333333334)
+	assert.Equal(t, votes{n: 4}, votes{n: 10}.keep(third, true))
+	assert.Equal(t, votes{n: 3}, votes{n: 10}.keep(third, false))
+	assert.Equal(t, votes{n: 10}, votes{n: 10}.keep(keepOne, true))
+	assert.Equal(t, votes{}, votes{n: 10}.keep(0, true))
+
+	// beyond an int64, and back
+	huge := newVotes(new(big.Int).Lsh(big.NewInt(1), 70))
+	assert.NotNil(t, huge.b)
+	assert.Equal(t, "590295810358705651712", huge.keep(keepOne/2, true).big().String())
+	assert.Equal(t, votes{n: 1 << 62}, huge.div(1<<8, false))
+	assert.Equal(t, votes{n: 1<<62 + 1}, huge.add(votes{n: 1}).div(1<<8, true))
+}
+
+func TestKeepFactor_Update(t *testing.T) {
+	// a winner with 35 votes keeping a quota of 27.5 keeps 0.785714285..., rounded up
+	assert.Equal(t, keepFactor(785714286), keepOne.update(votes{n: 275}, votes{n: 350}))
+	assert.Equal(t, keepFactor(392857143), keepFactor(785714286).update(votes{n: 1}, votes{n: 2}))
+
+	q := newVotes(new(big.Int).Lsh(big.NewInt(1), 80))
+	v := q.add(q)
+	assert.Equal(t, keepOne/2, keepOne.update(q, v))
+}
+
+func TestScale(t *testing.T) {
+	e := &election.Election{Ballots: []election.Ballot{
+		{Weight: election.NewWeight(2)},
+		{Weight: mustWeight(t, "0.0000000000025")},
+	}}
+	s := newScale(e)
+	assert.Equal(t, 13, s.decimals)
+	ws := s.weights(e)
+	assert.Equal(t, votes{n: 2e13}, ws[0])
+	assert.Equal(t, votes{n: 25}, ws[1])
+	assert.Equal(t, 2.0000000000025, s.float64(ws[0].add(ws[1])))
+	assert.Equal(t, 9, newScale(&election.Election{}).decimals)
+}
+
+func mustWeight(t *testing.T, s string) election.Weight {
+	t.Helper()
+	w, err := election.ParseWeight(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return w
+}
@@ -0,0 +1,67 @@
+package meekstv
+
+import (
+	"bytes"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/linuxfoundation-it/meek-stv/election"
+)
+
+func FuzzCount(f *testing.F) {
+	files, err := filepath.Glob("../testdata/*.txt")
+	if err != nil {
+		f.Fatal(err)
+	}
+	for _, name := range files {
+		data, err := os.ReadFile(name)
+		if err != nil {
+			f.Fatal(err)
+		}
+		f.Add(data)
+	}
+	// exact ties at the quota
+	f.Add([]byte("2 1\n1 1 0\n1 2 0\n0\n\"A\"\n\"B\"\n\"Tie\"\n"))
+	// no votes at all
+	f.Add([]byte("3 2\n-1\n4 0\n2 1 0\n0\n\"A\"\n\"B\"\n\"C\"\n\"Empty\"\n"))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		e, err := election.Parse(bytes.NewReader(data))
+		if err != nil {
+			return
+		}
+
+		report := Count(e)
+
+		nonWithdrawn := 0
+		for i := 0; i < e.Candidates; i++ {
+			if !e.Withdrawn[i] {
+				nonWithdrawn++
+			}
+		}
+		winners := report.Winners()
+		if want := min(e.Seats, nonWithdrawn); len(winners) != want {
+			t.Fatalf("elected %d candidates, want %d", len(winners), want)
+		}
+		for _, w := range winners {
+			if e.Withdrawn[w] {
+				t.Fatalf("elected withdrawn candidate %d", w)
+			}
+		}
+
+		valid := e.TotalWeight().Float64() - e.CountEmpty().Float64()
+		tol := 1e-6 * math.Max(valid, 1)
+		for _, entry := range report.entries {
+			total := entry.Exhausted
+			for _, c := range entry.CandidateSnapshot {
+				total += c.Votes
+			}
+			if math.Abs(total-valid) > tol {
+				t.Fatalf("round %d: votes and exhausted sum to %f, want %f", entry.Round, total, valid)
+			}
+		}
+		checkTransferMatrix(t, &report)
+	})
+}
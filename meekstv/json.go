@@ -0,0 +1,147 @@
+package meekstv
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// SchemaVersion is the version of the JSON document written by Log.WriteJSON.
+// Fields may be added without changing it, but it is incremented whenever a field
+// is renamed, removed or changes meaning.
+const SchemaVersion = 1
+
+// Document is the machine-readable form of a Log.
+type Document struct {
+	SchemaVersion int             `json:"schema_version"`
+	Rounds        []RoundDocument `json:"rounds"`
+}
+
+// RoundDocument describes one round of the count. Candidates are listed by index,
+// Elected and Defeated hold the indices of the candidates elected and defeated at the end of the round.
+type RoundDocument struct {
+	Round      int     `json:"round"`
+	Threshold  float64 `json:"threshold"`
+	TotalVotes float64 `json:"total_votes"`
+	Exhausted  float64 `json:"exhausted"`
+	// ExhaustedBreakdown splits the exhausted votes by cause
+	ExhaustedBreakdown ExhaustedBreakdown  `json:"exhausted_breakdown"`
+	Candidates         []CandidateDocument `json:"candidates"`
+	Elected            []int               `json:"elected"`
+	Defeated           []int               `json:"defeated"`
+
+	// SurplusTransfers is set when the previous round elected candidates,
+	// EliminationTransfers when it defeated one.
+	SurplusTransfers     *TransfersDocument `json:"surplus_transfers,omitempty"`
+	EliminationTransfers *TransfersDocument `json:"elimination_transfers,omitempty"`
+
+	// Transfers is the matrix of the votes each candidate, by index, gave to every other candidate
+	// since the previous round. Its last row and column stand for exhausted votes. It is omitted in
+	// the first round.
+	Transfers [][]float64 `json:"transfers,omitempty"`
+}
+
+type CandidateDocument struct {
+	Index      int            `json:"index"`
+	Name       string         `json:"name"`
+	State      CandidateState `json:"state"`
+	KeepFactor float64        `json:"keep_factor"`
+	Votes      float64        `json:"votes"`
+	Surplus    float64        `json:"surplus"`
+	// Class is the seat class of the seat an elected candidate fills, omitted for open seats
+	Class string `json:"class,omitempty"`
+}
+
+// TransfersDocument lists the votes gained by each candidate since the previous round,
+// ordered by candidate index, and the change in exhausted votes.
+type TransfersDocument struct {
+	Received  []TransferDocument `json:"received"`
+	Exhausted float64            `json:"exhausted"`
+}
+
+type TransferDocument struct {
+	Candidate int     `json:"candidate"`
+	Name      string  `json:"name"`
+	Votes     float64 `json:"votes"`
+}
+
+// Document returns the rounds of the count in their machine-readable form.
+func (l *Log) Document() *Document {
+	doc := &Document{
+		SchemaVersion: SchemaVersion,
+		Rounds:        make([]RoundDocument, 0, len(l.entries)),
+	}
+	for _, e := range l.entries {
+		r := RoundDocument{
+			Round:              e.Round,
+			Threshold:          e.Threshold,
+			TotalVotes:         e.TotVotes,
+			Exhausted:          e.Exhausted,
+			ExhaustedBreakdown: e.ExhaustedBreakdown,
+			Candidates:         make([]CandidateDocument, 0, len(e.CandidateSnapshot)),
+			Elected:            indices(e.Elected),
+			Defeated:           indices(e.Defeated),
+		}
+		for _, c := range e.CandidateSnapshot {
+			r.Candidates = append(r.Candidates, CandidateDocument{
+				Index:      c.Index,
+				Name:       c.Name,
+				State:      c.State,
+				KeepFactor: c.KeepFactor,
+				Votes:      c.Votes,
+				Surplus:    c.Surplus,
+				Class:      c.Class,
+			})
+		}
+		if e.Transfers != nil {
+			r.Transfers = e.Transfers.float64s()
+		}
+		if e.SurplusReceived != nil {
+			r.SurplusTransfers = transfersDocument(e.SurplusReceived, e.SurplusExhaustedDelta, e.CandidateSnapshot)
+		}
+		if e.EliminationReceived != nil {
+			r.EliminationTransfers = transfersDocument(e.EliminationReceived, e.EliminationExhaustedDelta, e.CandidateSnapshot)
+		}
+		doc.Rounds = append(doc.Rounds, r)
+	}
+	return doc
+}
+
+func (l *Log) MarshalJSON() ([]byte, error) {
+	return json.Marshal(l.Document())
+}
+
+// WriteJSON writes the indented JSON document of the count to w.
+func (l *Log) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(l.Document())
+}
+
+// ReadDocument reads a JSON document written by WriteJSON. It fails if the document
+// was written with a different schema version.
+func ReadDocument(r io.Reader) (*Document, error) {
+	var doc Document
+	if err := json.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, err
+	}
+	if doc.SchemaVersion != SchemaVersion {
+		return nil, fmt.Errorf("unsupported schema version %d, expected %d", doc.SchemaVersion, SchemaVersion)
+	}
+	return &doc, nil
+}
+
+func indices(cs []Candidate) []int {
+	out := make([]int, 0, len(cs))
+	for _, c := range cs {
+		out = append(out, c.Index)
+	}
+	return out
+}
+
+func transfersDocument(received map[int]float64, exhausted float64, snapshot []Candidate) *TransfersDocument {
+	return &TransfersDocument{
+		Received:  sortedTransfers(received, snapshot, ByIndex),
+		Exhausted: exhausted,
+	}
+}
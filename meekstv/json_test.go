@@ -0,0 +1,87 @@
+package meekstv_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/linuxfoundation-it/meek-stv/meekstv"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLog_WriteJSON(t *testing.T) {
+	e := readBallots(t, "../testdata/election13.txt")
+	report := meekstv.Count(e)
+
+	var buf bytes.Buffer
+	require.NoError(t, report.WriteJSON(&buf))
+	assert.Contains(t, buf.String(), `"state": "elected"`)
+
+	doc, err := meekstv.ReadDocument(&buf)
+	require.NoError(t, err)
+	assert.Equal(t, meekstv.SchemaVersion, doc.SchemaVersion)
+	require.Len(t, doc.Rounds, report.NumRounds())
+
+	for i, r := range doc.Rounds {
+		entry := report.Round(i)
+		assert.Equal(t, entry.Round, r.Round)
+		assert.Equal(t, entry.Threshold, r.Threshold)
+		assert.Equal(t, entry.TotVotes, r.TotalVotes)
+		assert.Equal(t, entry.Exhausted, r.Exhausted)
+		require.Len(t, r.Candidates, len(entry.CandidateSnapshot))
+		for j, c := range r.Candidates {
+			assert.Equal(t, entry.CandidateSnapshot[j].State, c.State)
+			assert.Equal(t, entry.CandidateSnapshot[j].Votes, c.Votes)
+		}
+		assert.Len(t, r.Elected, len(entry.Elected))
+		assert.Len(t, r.Defeated, len(entry.Defeated))
+
+		if entry.EliminationReceived != nil {
+			require.NotNil(t, r.EliminationTransfers)
+			assert.Len(t, r.EliminationTransfers.Received, len(entry.EliminationReceived))
+			for k := 1; k < len(r.EliminationTransfers.Received); k++ {
+				assert.Less(t, r.EliminationTransfers.Received[k-1].Candidate, r.EliminationTransfers.Received[k].Candidate)
+			}
+		}
+	}
+
+	var winners []int
+	for _, c := range doc.Rounds[len(doc.Rounds)-1].Candidates {
+		if c.State == meekstv.Elected {
+			winners = append(winners, c.Index)
+		}
+	}
+	assert.ElementsMatch(t, report.Winners(), winners)
+}
+
+func TestLog_MarshalJSON_Deterministic(t *testing.T) {
+	e := readBallots(t, "../testdata/election12.txt")
+	report := meekstv.Count(e)
+
+	a, err := json.Marshal(&report)
+	require.NoError(t, err)
+	b, err := json.Marshal(&report)
+	require.NoError(t, err)
+	assert.Equal(t, a, b)
+}
+
+func TestReadDocument_SchemaVersion(t *testing.T) {
+	_, err := meekstv.ReadDocument(strings.NewReader(`{"schema_version": 99, "rounds": []}`))
+	assert.Error(t, err)
+}
+
+func TestCandidateState_Text(t *testing.T) {
+	for _, s := range []meekstv.CandidateState{meekstv.Hopeful, meekstv.Withdrawn, meekstv.Defeated, meekstv.Elected} {
+		text, err := s.MarshalText()
+		require.NoError(t, err)
+
+		var got meekstv.CandidateState
+		require.NoError(t, got.UnmarshalText(text))
+		assert.Equal(t, s, got)
+	}
+
+	var s meekstv.CandidateState
+	assert.Error(t, s.UnmarshalText([]byte("resigned")))
+}
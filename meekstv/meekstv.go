@@ -1,49 +1,64 @@
 package meekstv
 
 import (
-	"math"
+	"sort"
 
 	"github.com/linuxfoundation-it/meek-stv/election"
 )
 
-func Count(params *election.Election) Log {
-	// Initialize Election
-	getInitialState := func(i int) CandidateState {
-		if params.Withdrawn[i] {
-			return Withdrawn
-		}
-		return Hopeful
-	}
-	getInitialKeepFactor := func(i int) float64 {
-		if params.Withdrawn[i] {
-			return 0.0
-		}
-		return 1.0
-	}
+func Count(params *election.Election, opts ...Option) Log {
+	return count(params, newCandidates(params), newConfig(opts))
+}
 
-	// Set each candidate’s state to hopeful or withdrawn.
-	// Set each hopeful candidate’s keep factor kf to 1, and each withdrawn candidate’s keep factor to 0.
+// newCandidates sets each candidate’s state to hopeful or withdrawn.
+// Set each hopeful candidate’s keep factor kf to 1, and each withdrawn candidate’s keep factor to 0.
+func newCandidates(params *election.Election) Candidates {
 	cs := make(Candidates, params.Candidates)
 	for i := 0; i < params.Candidates; i++ {
 		cs[i] = &Candidate{
 			Index:      i,
 			Name:       params.CandidateNames[i],
-			State:      getInitialState(i),
-			KeepFactor: getInitialKeepFactor(i),
+			State:      Hopeful,
+			KeepFactor: 1.0,
+			keep:       keepOne,
+		}
+		if params.Withdrawn[i] {
+			cs[i].State = Withdrawn
+			cs[i].KeepFactor = 0.0
+			cs[i].keep = 0
 		}
 	}
+	return cs
+}
 
+// count runs the rounds of the count from the initial state of the candidates.
+// Candidates already elected are elected in the first round whatever their votes.
+func count(params *election.Election, cs Candidates, cfg config) Log {
 	// Test count complete. Proceed to step C if all seats are filled,
 	// or if the number of elected plus hopeful candidates is less than or equal to the number of seats.
 
+	scale := newScale(params)
 	round := &meekStvRound{
 		omega:      1 / 10e6,
+		scale:      scale,
+		weights:    scale.weights(params),
 		candidates: cs,
+		locked:     make(map[int]bool),
+		seats:      newSeatLedger(params),
+	}
+	for _, c := range cs {
+		if c.State == Elected {
+			round.locked[c.Index] = true
+			c.Class = round.seats.fill(c.Index)
+		}
 	}
 	for ; ; round.n++ {
-		hopeful := cs.countState(Hopeful)
+		if err := cfg.ctx.Err(); err != nil {
+			round.report.err = err
+			return round.report
+		}
 		elected := cs.countState(Elected)
-		if elected >= params.Seats || elected+hopeful <= params.Seats {
+		if elected >= params.Seats || round.seats.fits(cs) || round.stalled {
 			// Ensure there is a round entry before accessing the last log entry
 			if round.report.NumRounds() == 0 {
 				round.report.add(round.n)
@@ -55,6 +70,9 @@ func Count(params *election.Election) Log {
 			// Found edge case where the last round was not being logged when a hopeful candidate was elected
 			roundLog := round.report.last()
 			roundLog.CandidateSnapshot = round.snapshot()
+			if cfg.provenance {
+				round.report.provenance = newProvenance(params, round.scale, round.weights, round.prevKeep)
+			}
 			return round.report
 		}
 		round.report.add(round.n)
@@ -63,6 +81,9 @@ func Count(params *election.Election) Log {
 		// failsafe in case bugs prevent the loop from exiting
 		if round.n >= 50 {
 			round.complete(params.Seats)
+			if cfg.provenance {
+				round.report.provenance = newProvenance(params, round.scale, round.weights, round.prevKeep)
+			}
 			return round.report
 		}
 	}
@@ -73,9 +94,19 @@ type meekStvRound struct {
 	n           int
 	candidates  Candidates
 	omega       float64
-	threshold   float64
-	prevSurplus float64
-	report      Log
+	threshold   votes
+	prevSurplus votes
+	// scale of the votes, and the weight of every ballot in it
+	scale   scale
+	weights []votes
+	// keep factors the ballots were distributed with in the previous round
+	prevKeep []keepFactor
+	// candidates elected before the count, see Countback
+	locked map[int]bool
+	// stalled is set when no candidate could be elected or defeated in the last round
+	stalled bool
+	seats   *seatLedger
+	report  Log
 }
 
 func (round *meekStvRound) run(input *election.Election) {
@@ -88,134 +119,97 @@ func (round *meekStvRound) run(input *election.Election) {
 	// add w multiplied by the keep factor kf of the candidate (to 9 decimal places, rounded up)
 	// to that candidate’s vote v, and reduce w by the same amount, until no further candidate remains
 	// on the ballot or until the ballot’s weight w is 0.
-
-	exhausted := 0.0
-	for _, bl := range input.Ballots {
-		w := float64(bl.Weight)
-		for i := 0; i < len(bl.Preferences); i++ {
-			c := round.candidates[bl.Preferences[i]]
-			v := w * c.KeepFactor
-			c.Votes += v
-			w -= v
-			if w <= 0.0 {
-				break
-			}
-		}
-		if w > 0.0 {
-			exhausted += w
-		}
+	keep := round.keepFactors()
+	exhausted, breakdown, transfers := distribute(input, round.weights, round.scale, round.candidates, round.prevKeep)
+	round.prevKeep = keep
+	for _, c := range round.candidates {
+		c.Votes = round.scale.float64(c.votes)
 	}
+
 	// get log entry
 	roundLog := round.report.last()
 
 	// log
-	roundLog.Exhausted = exhausted - float64(input.CountEmpty())
+	exhausted = exhausted.sub(round.scale.votes(input.CountEmpty()))
+	roundLog.Exhausted = round.scale.float64(exhausted)
+	roundLog.exhausted = exhausted
 
 	// Update quota. Set quota q to the sum of the vote v for all candidates (step B.2.a),
 	// divided by one more than the number of seats to be filled,
-	// truncated to 9 decimal places, plus 0.000000001 (1/109).
+	// rounded up to the precision of the votes, so that a candidate reaches it exactly when
+	// it reaches the exact quotient.
 	totvotes := round.candidates.countVotes()
-	threshold := totvotes / (1.0 + float64(input.Seats))
-	round.threshold = threshold
+	round.threshold = totvotes.div(int64(input.Seats)+1, true)
 
 	// log
-	roundLog.Threshold = threshold
-	roundLog.TotVotes = totvotes
+	roundLog.Threshold = round.scale.float64(round.threshold)
+	roundLog.TotVotes = round.scale.float64(totvotes)
+	breakdown.Rounding = round.scale.float64(round.scale.votes(validWeight(input)).sub(totvotes).sub(exhausted))
+	roundLog.ExhaustedBreakdown = breakdown
 
 	// Find winners. Elect each hopeful candidate with a vote v greater than or equal to the quota (v ≥ q).
+	// Locked candidates are elected in the first round, and keep all their votes if they are below the quota.
+	winners := round.reachedQuota()
 	for _, c := range round.candidates {
-		if c.Votes >= round.threshold && c.State != Elected {
+		class, won := winners[c.Index]
+		if won || round.locked[c.Index] {
+			if won {
+				c.Class = class
+			}
 			c.State = Elected
 			newlyElected = true
 
 			// Update keep factors. Set the keep factor kf of each elected candidate to the candidate’s
 			// current keep factor kf, multiplied by the current quota q (to 9 decimal places, rounded up),
 			// and then divided by the candidate’s current vote v (to 9 decimal places, rounded up).
-			c.KeepFactor = (c.KeepFactor * round.threshold) / c.Votes
+			// A candidate with no votes only reaches a zero quota, and keeps everything.
+			if c.votes.cmp(round.threshold) > 0 {
+				c.keep = c.keep.update(round.threshold, c.votes)
+				c.KeepFactor = c.keep.Float64()
+			}
 
 			// log
 			roundLog.Elected = append(roundLog.Elected, *c)
 		}
 	}
+	round.locked = nil
 	roundLog.CandidateSnapshot = round.snapshot()
 
-	// Compute transfer breakdowns relative to previous round's event using Candidate.Index keys
+	// Defeat the hopeful candidates left without a seat while seats remain to be filled,
+	// which only happens when the other seats are reserved for classes they don't belong to.
+	if round.candidates.countState(Elected) < input.Seats {
+		for _, c := range round.candidates {
+			if _, ok := round.seats.seat(c.Index); c.State == Hopeful && !ok {
+				c.State = Defeated
+				c.KeepFactor = 0.0
+				c.keep = 0
+				roundLog.Defeated = append(roundLog.Defeated, *c)
+			}
+		}
+	}
+
+	// Transfer breakdowns relative to the previous round's event: the candidates elected or defeated
+	// at the end of it are the only ones whose keep factor changed, hence the only sources.
+	roundLog.Transfers = transfers
 	if len(round.report.entries) >= 2 {
 		prev := round.report.entries[len(round.report.entries)-2]
-		prevSnap := prev.CandidateSnapshot
-		curSnap := roundLog.CandidateSnapshot
-		// build maps by candidate Index
-		prevByIdx := make(map[int]Candidate, len(prevSnap))
-		curByIdx := make(map[int]Candidate, len(curSnap))
-		for i := range prevSnap {
-			prevByIdx[prevSnap[i].Index] = prevSnap[i]
-		}
-		for i := range curSnap {
-			curByIdx[curSnap[i].Index] = curSnap[i]
-		}
-		// If previous round elected candidate(s), attribute positive deltas by index
 		if len(prev.Elected) > 0 {
-			m := make(map[int]float64)
-			// We measure EFFECTS, not SOURCES: after an election, surplus leaves elected
-			// candidates via reduced keep factors and flows to next preferences when
-			// ballots are re-walked. Any candidate can become a recipient of that flow.
-			//
-			// Therefore we scan ALL candidates and compute per-candidate deltas between
-			// consecutive snapshots (current - previous). We only record POSITIVE deltas
-			// as "surplus received". Elected candidates usually have NEGATIVE deltas (they
-			// shed surplus), which are naturally ignored by the > 0 filter. If multiple
-			// candidates were elected in the previous round, their combined drop is captured
-			// on the recipient side as the sum of positive deltas across all other
-			// candidates (plus any additional exhausted delta tracked separately).
-			//
-			// Important: attribution uses Candidate.Index as the stable key so that we do
-			// not depend on slice ordering of snapshots.
-			for idx, curC := range curByIdx {
-				if prevC, ok := prevByIdx[idx]; ok {
-					delta := curC.Votes - prevC.Votes
-					if delta > 0 {
-						m[idx] = delta
-					}
-				}
-			}
-			roundLog.SurplusReceived = m
-			// Track the exhausted delta separately. Together, sum(SurplusReceived) +
-			// SurplusExhaustedDelta ≈ total drop in votes across the elected candidates.
-			// this is not used in the current implementation until we allow
-			// to only rank a subset of candidates
-			roundLog.SurplusExhaustedDelta = roundLog.Exhausted - prev.Exhausted
+			roundLog.SurplusReceived, roundLog.SurplusExhaustedDelta = transfers.received(prev.Elected)
 		}
-		// If previous round eliminated a candidate, attribute positive deltas by index
 		if len(prev.Defeated) > 0 {
-			m := make(map[int]float64)
-			defeatedIdx := prev.Defeated[0].Index
-			// For an elimination, the eliminated candidate is the SOURCE and must be
-			// excluded from recipients. There is only one eliminated candidate per round.
-			for idx, curC := range curByIdx {
-				// skip the eliminated candidate
-				if idx == defeatedIdx {
-					continue
-				}
-				if prevC, ok := prevByIdx[idx]; ok {
-					delta := curC.Votes - prevC.Votes
-					if delta > 0 {
-						m[idx] = delta
-					}
-				}
-			}
-			roundLog.EliminationReceived = m
-			// this is not used in the current implementation until we allow
-			// to only rank a subset of candidates
-			roundLog.EliminationExhaustedDelta = roundLog.Exhausted - prev.Exhausted
+			roundLog.EliminationReceived, roundLog.EliminationExhaustedDelta = transfers.received(prev.Defeated)
 		}
 	}
 
 	// Calculate the total surplus s, as the sum of the individual surpluses (v – q) of the elected candidates,
 	// but not less than 0.
-	totSurplus := 0.0
+	var totSurplus votes
 	for _, c := range round.candidates {
-		c.Surplus = math.Max(c.Votes-round.threshold, 0.0)
-		totSurplus += c.Surplus
+		c.Surplus = 0.0
+		if surplus := c.votes.sub(round.threshold); surplus.sign() > 0 {
+			c.Surplus = round.scale.float64(surplus)
+			totSurplus = totSurplus.add(surplus)
+		}
 	}
 
 	// Test for iteration finished. If step B.2.c elected a candidate, continue at B.1.
@@ -226,7 +220,7 @@ func (round *meekStvRound) run(input *election.Election) {
 
 	// Otherwise, if the total surplus s is less than omega, or (except for the first iteration)
 	// if the total surplus s is greater than or equal to the surplus s in the previous iteration, continue at B.3.
-	if totSurplus < round.omega || (round.n > 0 && totSurplus >= round.prevSurplus) {
+	if round.scale.float64(totSurplus) < round.omega || (round.n > 0 && totSurplus.cmp(round.prevSurplus) >= 0) {
 		// continue
 	}
 
@@ -234,15 +228,23 @@ func (round *meekStvRound) run(input *election.Election) {
 	// Defeat the hopeful candidate c with the lowest vote v, breaking any tie per procedure T,
 	// where each candidate c' is tied with c if vote v' for c' is less than or equal to v plus total surplus s.
 	// Set the keep factor kf of c to 0.
-	var d = &Candidate{Votes: math.MaxFloat64}
+	// Candidates needed to fill the seats reserved for their class are not defeated.
+	var d *Candidate
 	for _, c := range round.candidates {
-		if c.State == Hopeful && c.Votes < d.Votes {
+		if c.State == Hopeful && (d == nil || c.votes.cmp(d.votes) < 0) && !round.seats.protected(c.Index, round.candidates) {
 			d = c
 		}
 	}
+	// When every hopeful candidate is protected, none is defeated and the count completes.
+	if d == nil {
+		round.stalled = true
+		round.prevSurplus = totSurplus
+		return
+	}
 
 	d.State = Defeated
 	d.KeepFactor = 0.0
+	d.keep = 0
 
 	// log
 	roundLog.Defeated = append(roundLog.Defeated, *d)
@@ -251,11 +253,43 @@ func (round *meekStvRound) run(input *election.Election) {
 	round.prevSurplus = totSurplus
 }
 
+// reachedQuota gives a seat to the hopeful candidates with a vote v greater than or equal to the quota,
+// and returns the class of the seat each of them fills. When more of them reach the quota than there
+// are seats left for them, which happens with exactly tied votes or reserved seats, only those with
+// the most votes are returned.
+func (round *meekStvRound) reachedQuota() map[int]string {
+	var reached []*Candidate
+	for _, c := range round.candidates {
+		if c.State == Hopeful && c.votes.cmp(round.threshold) >= 0 {
+			reached = append(reached, c)
+		}
+	}
+	sort.SliceStable(reached, func(i, j int) bool {
+		return reached[i].votes.cmp(reached[j].votes) > 0
+	})
+
+	out := make(map[int]string, len(reached))
+	for _, c := range reached {
+		if _, ok := round.seats.seat(c.Index); ok {
+			out[c.Index] = round.seats.fill(c.Index)
+		}
+	}
+	return out
+}
+
 // TODO
 // tiebreaking
 // Ties can arise in B.3, when selecting a candidate for defeat.
 // Use the defined tiebreaking procedure to select for defeat one candidate from the group of tied candidates.
 
+func (round *meekStvRound) keepFactors() []keepFactor {
+	kfs := make([]keepFactor, len(round.candidates))
+	for i, c := range round.candidates {
+		kfs[i] = c.keep
+	}
+	return kfs
+}
+
 func (round *meekStvRound) snapshot() []Candidate {
 	snap := make([]Candidate, len(round.candidates))
 	for i, c := range round.candidates {
@@ -268,11 +302,16 @@ func (round *meekStvRound) complete(seats int) Candidates {
 	elected := round.candidates.countState(Elected)
 	candidates := round.candidates
 
-	// Elect remaining. If any seats are unfilled, elect remaining hopeful candidates.
-	for i := 0; elected < seats && i < len(candidates); i++ {
-		if candidates[i].State == Hopeful {
-			candidates[i].State = Elected
-			elected++
+	// Elect remaining. If any seats are unfilled, elect remaining hopeful candidates,
+	// those filling the seats reserved for their class first.
+	for _, reserved := range []bool{true, false} {
+		for i := 0; elected < seats && i < len(candidates); i++ {
+			c := candidates[i]
+			if _, ok := round.seats.seat(c.Index); c.State == Hopeful && ok && round.seats.reserves(c.Index) == reserved {
+				c.Class = round.seats.fill(c.Index)
+				c.State = Elected
+				elected++
+			}
 		}
 	}
 
@@ -1,127 +1,193 @@
-package meekstv
+package meekstv_test
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
-	"math"
+	"flag"
+	"fmt"
+	"io"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/linuxfoundation-it/meek-stv/election"
+	"github.com/linuxfoundation-it/meek-stv/meekstv"
+	"github.com/linuxfoundation-it/meek-stv/opavote"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
-func TestCount(t *testing.T) {
-	t.Run("so election 14", func(t *testing.T) {
-		got, want := load("election14")
-		assertAll(t, want, got)
-	})
-
-	t.Run("so election 13", func(t *testing.T) {
-		got, want := load("election13")
-		assertAll(t, want, got)
-	})
-
-	t.Run("so election 12", func(t *testing.T) {
-		got, want := load("election12")
-		assertAll(t, want, got)
-	})
-
-	t.Run("so election 11", func(t *testing.T) {
-		got, want := load("election11")
-		assertAll(t, want, got)
-	})
-
-	t.Run("so election 10", func(t *testing.T) {
-		t.Skip() // failing due to precision on fractional votes
-		got, want := load("election10")
-		assert.ElementsMatch(t, want.Winners, got.Winners(), "winners mismatch")
-	})
-
-	t.Run("medical sciences 2022", func(t *testing.T) {
-		got, want := load("medsci2022")
-		assert.ElementsMatch(t, want.Winners, got.Winners(), "winners mismatch")
-	})
-
-	t.Run("chinese 2020", func(t *testing.T) {
-		t.Skip() // failing due to precision on fractional votes
-		got, want := load("chinese2020")
-		assert.ElementsMatch(t, want.Winners, got.Winners(), "winners mismatch")
-	})
-}
+var update = flag.Bool("update", false, "regenerate the golden report files in testdata/golden")
 
-func assertAll(t *testing.T, want *OpaVoteJSONReport, got Log) {
-	assert.Equal(t, len(want.Rounds), got.NumRounds())
+const testdata = "../testdata"
 
-	omega := math.Pow(10, float64(want.Precision))
+// divergence is a known difference between a count and its OpaVote report: the rounds
+// before it are compared, and those after it are expected to differ
+type divergence struct {
+	Rounds int
+	Reason string
+}
 
-	for i, wantRound := range want.Rounds {
-		gotRound := got.Round(i)
-		assert.Equal(t, wantRound.N, gotRound.Round+1, "round number mismatch")
-		assert.InDeltaf(t, float64(wantRound.Thresh)/omega, gotRound.Threshold, 0.01, "round %d threshold", wantRound.N)
+// loadDivergences reads the known differences with OpaVote, by election
+func loadDivergences(t *testing.T) map[string]divergence {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(testdata, "divergences.json"))
+	require.NoError(t, err)
+	var out map[string]divergence
+	require.NoError(t, json.Unmarshal(data, &out))
+	return out
+}
 
-		for i, votes := range wantRound.Count {
-			assert.InDeltaf(t, float64(votes)/omega, gotRound.VotesOf(i), 0.01, "round %d votes of %d", wantRound.N, i)
+// TestCount counts every testdata/<name>.txt ballot file that has a matching
+// testdata/<name>.json OpaVote report, and compares the count round by round
+// with OpaVote and with our own golden report in testdata/golden/<name>.golden.
+// Every round must agree, except after the known divergences of testdata/divergences.json.
+func TestCount(t *testing.T) {
+	controls, err := filepath.Glob(filepath.Join(testdata, "*.json"))
+	require.NoError(t, err)
+	divergences := loadDivergences(t)
+
+	for _, control := range controls {
+		name := strings.TrimSuffix(filepath.Base(control), ".json")
+		ballots := filepath.Join(testdata, name+".txt")
+		if _, err := os.Stat(ballots); err != nil {
+			continue
 		}
-		assert.InDeltaf(t, float64(wantRound.Exhausted)/omega, gotRound.Exhausted, 0.01, "round %d exhausted", wantRound.N)
+
+		t.Run(name, func(t *testing.T) {
+			e := readBallots(t, ballots)
+			want, err := opavote.LoadFile(control)
+			require.NoError(t, err)
+			got := meekstv.Count(e)
+
+			assert.ElementsMatch(t, want.Winners, got.Winners(), "winners mismatch")
+
+			d, known := divergences[name]
+			if !known {
+				require.NoError(t, opavote.Supported(want), "list the election in divergences.json to count it anyway")
+				d.Rounds = len(want.Rounds)
+			}
+			if diff := diffRounds(want, &got, e, d.Rounds); diff != "" {
+				t.Errorf("count differs from OpaVote in the first %d rounds:\n%s", d.Rounds, diff)
+			}
+			if known {
+				assert.NotEmpty(t, diffRounds(want, &got, e, len(want.Rounds)),
+					"the count agrees with OpaVote in every round, remove it from divergences.json (%s)", d.Reason)
+			}
+
+			assertGolden(t, filepath.Join(testdata, "golden", name+".golden"), golden(&got))
+		})
 	}
-	assert.ElementsMatch(t, want.Winners, got.Winners(), "winners mismatch")
 }
 
-func load(filename string) (Log, *OpaVoteJSONReport) {
-	return Count(readBallots(filename)), readControl(filename)
+// diffRounds compares the first n rounds of got with the OpaVote report
+// and returns a readable description of the differences.
+func diffRounds(want *opavote.Report, got *meekstv.Log, e *election.Election, n int) string {
+	report := opavote.FromLog(got, e, want.Precision)
+
+	if n < len(want.Rounds) {
+		truncated := *want
+		truncated.Rounds = want.Rounds[:n]
+		want = &truncated
+		if n < len(report.Rounds) {
+			report.Rounds = report.Rounds[:n]
+		}
+	}
+
+	var out strings.Builder
+	for _, m := range opavote.Compare(want, report, opavote.DefaultTolerance) {
+		fmt.Fprintln(&out, m)
+	}
+	return out.String()
 }
 
-func readBallots(name string) *election.Election {
-	f, err := os.Open("../testdata/" + name + ".txt")
-	if err != nil {
-		panic(err)
+// golden renders every round of a count with full precision and in a stable order
+func golden(l *meekstv.Log) []byte {
+	var b bytes.Buffer
+	for i := 0; i < l.NumRounds(); i++ {
+		e := l.Round(i)
+		fmt.Fprintf(&b, "round %d\n", e.Round+1)
+		fmt.Fprintf(&b, "threshold %.6f\n", e.Threshold)
+		fmt.Fprintf(&b, "total %.6f\n", e.TotVotes)
+		fmt.Fprintf(&b, "exhausted %.6f\n", e.Exhausted)
+		for _, c := range e.CandidateSnapshot {
+			fmt.Fprintf(&b, "candidate %d %q state %d keep %.6f votes %.6f\n", c.Index, c.Name, c.State, c.KeepFactor, c.Votes)
+		}
+		for _, c := range e.Elected {
+			fmt.Fprintf(&b, "elected %d %q\n", c.Index, c.Name)
+		}
+		for _, c := range e.Defeated {
+			fmt.Fprintf(&b, "defeated %d %q\n", c.Index, c.Name)
+		}
 	}
+	return b.Bytes()
+}
 
-	return election.Read(f)
+func assertGolden(t *testing.T, path string, got []byte) {
+	t.Helper()
+	if *update {
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, got, 0o644))
+		return
+	}
+	want, err := os.ReadFile(path)
+	require.NoError(t, err, "run go test -update to create the golden file")
+	assert.Equal(t, string(want), string(got), "report differs from %s, run go test -update if the change is expected", path)
 }
 
-type OpaVoteJSONReport struct {
-	NSeats      int                `json:"n_seats"`
-	NVotes      int                `json:"n_votes"`
-	Title       string             `json:"title"`
-	Precision   int                `json:"precision"`
-	Withdrawn   []int              `json:"withdrawn"`
-	Method      string             `json:"method"`
-	Version     string             `json:"version"`
-	Candidates  []string           `json:"candidates"`
-	Winners     []int              `json:"winners"`
-	TieBreaks   []interface{}      `json:"tie_breaks"`
-	Options     [][]interface{}    `json:"options"`
-	Rounds      []OpaVoteJSONRound `json:"rounds"`
-	NValidVotes int                `json:"n_valid_votes"`
+func readBallots(t *testing.T, path string) *election.Election {
+	t.Helper()
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+
+	e, err := election.Parse(f)
+	require.NoError(t, err)
+	e.Unquote()
+	return e
 }
 
-type OpaVoteJSONRound struct {
-	Count      []int  `json:"count"`
-	Surplus    int    `json:"surplus"`
-	Exhausted  int    `json:"exhausted"`
-	Continuing []int  `json:"continuing"`
-	N          int    `json:"n"`
-	Msg        string `json:"msg"`
-	Winners    []int  `json:"winners"`
-	Losers     []int  `json:"losers"`
-	Thresh     int64  `json:"thresh"`
-	Action     struct {
-		Type       string `json:"type"`
-		Candidates []int  `json:"candidates"`
-		Desc       string `json:"desc"`
-	} `json:"action,omitempty"`
+// expiringContext expires once Err has been called a given number of times, that is
+// after as many rounds of a count
+type expiringContext struct {
+	context.Context
+	checks int
 }
 
-func readControl(name string) *OpaVoteJSONReport {
-	f, err := os.Open("../testdata/" + name + ".json")
-	if err != nil {
-		panic(err)
+func (c *expiringContext) Err() error {
+	if c.checks == 0 {
+		return context.DeadlineExceeded
 	}
-	r := OpaVoteJSONReport{}
-	err = json.NewDecoder(f).Decode(&r)
-	if err != nil {
-		panic(err)
+	c.checks--
+	return nil
+}
+
+func TestCount_Context(t *testing.T) {
+	e := readBallots(t, filepath.Join(testdata, "election13.txt"))
+	full := meekstv.Count(e, meekstv.WithContext(context.Background()))
+	require.NoError(t, full.Err())
+	require.Equal(t, 3, full.NumRounds())
+
+	// the deadline expires after the first round: the count stops there
+	report := meekstv.Count(e, meekstv.WithContext(&expiringContext{Context: context.Background(), checks: 1}))
+	assert.ErrorIs(t, report.Err(), context.DeadlineExceeded)
+	require.Equal(t, 1, report.NumRounds())
+	assert.Equal(t, full.Round(0).Threshold, report.Round(0).Threshold)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	report = meekstv.Count(e, meekstv.WithContext(ctx))
+	assert.ErrorIs(t, report.Err(), context.Canceled)
+	assert.Zero(t, report.NumRounds())
+	assert.Nil(t, report.Results())
+	assert.Nil(t, report.Winners())
+	assert.Empty(t, report.Elected())
+	for _, format := range meekstv.Formats {
+		r, err := meekstv.NewRenderer(format, meekstv.DefaultRenderOptions)
+		require.NoError(t, err)
+		assert.NotPanics(t, func() { r.Render(io.Discard, &report) }, format)
 	}
-	return &r
+	assert.NotPanics(t, func() { report.Document() })
 }
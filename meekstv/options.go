@@ -0,0 +1,35 @@
+package meekstv
+
+import "context"
+
+// Option configures a count.
+type Option func(*config)
+
+type config struct {
+	provenance bool
+	ctx        context.Context
+}
+
+func newConfig(opts []Option) config {
+	cfg := config{ctx: context.Background()}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return cfg
+}
+
+// WithProvenance records, for every ballot line, how its weight ends up split among the
+// candidates in the final round. See Log.Provenance.
+func WithProvenance() Option {
+	return func(c *config) {
+		c.provenance = true
+	}
+}
+
+// WithContext stops the count at the start of the first round after ctx is done.
+// The log then holds the rounds counted so far, and Log.Err the error of ctx.
+func WithContext(ctx context.Context) Option {
+	return func(c *config) {
+		c.ctx = ctx
+	}
+}
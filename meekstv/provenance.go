@@ -0,0 +1,218 @@
+package meekstv
+
+import (
+	"encoding/csv"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/linuxfoundation-it/meek-stv/election"
+)
+
+// Provenance tells which ballots back each candidate at the end of the count.
+// Every ballot line of the election is split among the candidates with the keep factors of the
+// last distribution of the count, in the same fixed point, so that the votes a candidate gets from
+// the ballot lines add up exactly to its votes in the final round: a winner is backed by a quota's
+// worth of ballots, and by the part of its surplus that the count didn't pass on.
+type Provenance struct {
+	CandidateNames []string
+	Ballots        []BallotProvenance
+	scale          scale
+}
+
+// BallotProvenance is the split of the weight of a ballot line of the election.
+type BallotProvenance struct {
+	// Ballot is the index of the ballot line in the election, and ID the ID of the ballot, if any
+	Ballot      int
+	ID          string
+	Weight      float64
+	Preferences []int
+	// Equal marks the preferences ranked equal to the one before them, as in election.Ballot
+	Equal []bool
+	// Votes holds the votes given to each candidate, by index
+	Votes []float64
+	// Exhausted is the weight given to no candidate. Blank ballots are exhausted entirely.
+	Exhausted float64
+	votes     []votes
+}
+
+// Support is the share of a ballot line that backs a candidate.
+type Support struct {
+	Ballot int
+	Weight float64
+	Votes  float64
+}
+
+// newProvenance splits the ballots of e, of the given weights, with the keep factors kfs
+func newProvenance(e *election.Election, s scale, weights []votes, kfs []keepFactor) *Provenance {
+	p := &Provenance{
+		CandidateNames: e.CandidateNames,
+		Ballots:        make([]BallotProvenance, len(e.Ballots)),
+		scale:          s,
+	}
+	for i, b := range e.Ballots {
+		bp := BallotProvenance{
+			Ballot:      i,
+			ID:          b.ID,
+			Weight:      b.Weight.Float64(),
+			Preferences: b.Preferences,
+			Equal:       b.Equal,
+			Votes:       make([]float64, e.Candidates),
+			votes:       make([]votes, e.Candidates),
+		}
+		bp.Exhausted = s.float64(split(b, weights[i], kfs, bp.votes))
+		for c, v := range bp.votes {
+			bp.Votes[c] = s.float64(v)
+		}
+		p.Ballots[i] = bp
+	}
+	return p
+}
+
+// split adds the votes that ballot b of weight w gives to each candidate with keep factors kfs to out,
+// and returns the weight left exhausted. It walks the ballot the same way as distribute,
+// and rounds the votes the same way.
+func split(b election.Ballot, w votes, kfs []keepFactor, out []votes) votes {
+	for k := 0; k < len(b.Preferences) && w.sign() > 0; {
+		end := b.GroupEnd(k)
+		if end == k+1 {
+			c := b.Preferences[k]
+			v := w.keep(kfs[c], true)
+			out[c] = out[c].add(v)
+			w = w.sub(v)
+			k = end
+			continue
+		}
+
+		g := &group{candidates: b.Preferences[k:end]}
+		for _, c := range g.candidates {
+			if kfs[c] > 0 {
+				g.current++
+			}
+		}
+		var kept votes
+		for _, c := range g.candidates {
+			v := g.keeps(w, kfs[c], g.current)
+			out[c] = out[c].add(v)
+			kept = kept.add(v)
+		}
+		w = w.sub(kept)
+		k = end
+	}
+	return w
+}
+
+// Supporting returns the ballot lines that give votes to candidate c, by descending votes.
+func (p *Provenance) Supporting(c int) []Support {
+	var out []Support
+	for _, b := range p.Ballots {
+		if b.Votes[c] > 0 {
+			out = append(out, Support{Ballot: b.Ballot, Weight: b.Weight, Votes: b.Votes[c]})
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		return out[i].Votes > out[j].Votes
+	})
+	return out
+}
+
+// Total returns the votes of candidate c, summed over all ballot lines.
+func (p *Provenance) Total(c int) float64 {
+	var sum votes
+	for _, b := range p.Ballots {
+		sum = sum.add(b.votes[c])
+	}
+	return p.scale.float64(sum)
+}
+
+// WriteCSV writes a row per ballot line with its 1-based number in the ballot file, weight,
+// preferences, the votes it gives to every candidate and its exhausted weight.
+// Ballots with IDs have them written after their number.
+func (p *Provenance) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	ids := p.hasIDs()
+	header := append(ballotHeader(ids), "weight", "preferences")
+	header = append(header, p.CandidateNames...)
+	if err := cw.Write(append(header, "exhausted")); err != nil {
+		return err
+	}
+	for _, b := range p.Ballots {
+		row := append(p.ballotColumns(b.Ballot, ids),
+			formatVotes(b.Weight),
+			p.preferences(b.Preferences, b.Equal),
+		)
+		for _, v := range b.Votes {
+			row = append(row, formatVotes(v))
+		}
+		if err := cw.Write(append(row, formatVotes(b.Exhausted))); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteSupportCSV writes the ballot lines that back candidate c, by descending votes.
+func (p *Provenance) WriteSupportCSV(w io.Writer, c int) error {
+	cw := csv.NewWriter(w)
+	ids := p.hasIDs()
+	if err := cw.Write(append(ballotHeader(ids), "weight", "preferences", "votes")); err != nil {
+		return err
+	}
+	for _, s := range p.Supporting(c) {
+		err := cw.Write(append(p.ballotColumns(s.Ballot, ids),
+			formatVotes(s.Weight),
+			p.preferences(p.Ballots[s.Ballot].Preferences, p.Ballots[s.Ballot].Equal),
+			formatVotes(s.Votes),
+		))
+		if err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// hasIDs tells whether any ballot has an ID
+func (p *Provenance) hasIDs() bool {
+	for _, b := range p.Ballots {
+		if b.ID != "" {
+			return true
+		}
+	}
+	return false
+}
+
+func ballotHeader(ids bool) []string {
+	if ids {
+		return []string{"ballot", "id"}
+	}
+	return []string{"ballot"}
+}
+
+func (p *Provenance) ballotColumns(i int, ids bool) []string {
+	if ids {
+		return []string{strconv.Itoa(i + 1), p.Ballots[i].ID}
+	}
+	return []string{strconv.Itoa(i + 1)}
+}
+
+func (p *Provenance) preferences(prefs []int, equal []bool) string {
+	var sb strings.Builder
+	for i, c := range prefs {
+		switch {
+		case i == 0:
+		case i < len(equal) && equal[i]:
+			sb.WriteString(" = ")
+		default:
+			sb.WriteString(" > ")
+		}
+		sb.WriteString(p.CandidateNames[c])
+	}
+	return sb.String()
+}
+
+func formatVotes(v float64) string {
+	return strconv.FormatFloat(v, 'f', 9, 64)
+}
@@ -0,0 +1,103 @@
+package meekstv_test
+
+import (
+	"bytes"
+	"encoding/csv"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/linuxfoundation-it/meek-stv/meekstv"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProvenance(t *testing.T) {
+	files, err := filepath.Glob(filepath.Join(testdata, "*.txt"))
+	require.NoError(t, err)
+
+	for _, path := range files {
+		t.Run(strings.TrimSuffix(filepath.Base(path), ".txt"), func(t *testing.T) {
+			e := readBallots(t, path)
+			report := meekstv.Count(e, meekstv.WithProvenance())
+			p := report.Provenance()
+			require.NotNil(t, p)
+			require.Len(t, p.Ballots, len(e.Ballots))
+
+			// every ballot is split entirely among candidates and exhausted
+			for _, b := range p.Ballots {
+				sum := b.Exhausted
+				for _, v := range b.Votes {
+					sum += v
+				}
+				assert.InDelta(t, b.Weight, sum, 1e-9*b.Weight)
+			}
+
+			// the ballots backing every candidate add up exactly to its votes in the final round
+			for c, r := range report.Results() {
+				assert.Equal(t, r.Votes, p.Total(c), "candidate %d", c)
+			}
+		})
+	}
+}
+
+func TestProvenance_Quota(t *testing.T) {
+	e := readBallots(t, filepath.Join(testdata, "election13.txt"))
+	report := meekstv.Count(e, meekstv.WithProvenance())
+	p := report.Provenance()
+	final := report.Round(report.NumRounds() - 1)
+
+	// a quota's worth of ballots backs every winner, and the surplus the count didn't pass on
+	assert.InDelta(t, 9035, final.Threshold, 1e-6)
+	for _, w := range report.Winners() {
+		assert.GreaterOrEqual(t, p.Total(w), final.Threshold)
+		assert.Equal(t, final.VotesOf(w), p.Total(w))
+	}
+}
+
+func TestProvenance_Supporting(t *testing.T) {
+	e := readBallots(t, filepath.Join(testdata, "election13.txt"))
+	report := meekstv.Count(e, meekstv.WithProvenance())
+	p := report.Provenance()
+
+	for _, w := range report.Winners() {
+		support := p.Supporting(w)
+		require.NotEmpty(t, support)
+		total := 0.0
+		for i, s := range support {
+			assert.Greater(t, s.Votes, 0.0)
+			assert.LessOrEqual(t, s.Votes, s.Weight)
+			if i > 0 {
+				assert.LessOrEqual(t, s.Votes, support[i-1].Votes)
+			}
+			total += s.Votes
+		}
+		assert.InDelta(t, p.Total(w), total, 1e-6)
+	}
+
+	var b bytes.Buffer
+	require.NoError(t, p.WriteSupportCSV(&b, report.Winners()[0]))
+	rows, err := csv.NewReader(&b).ReadAll()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"ballot", "weight", "preferences", "votes"}, rows[0])
+	assert.Len(t, rows, len(p.Supporting(report.Winners()[0]))+1)
+}
+
+func TestProvenance_WriteCSV(t *testing.T) {
+	e := readBallots(t, filepath.Join(testdata, "election13.txt"))
+	report := meekstv.Count(e, meekstv.WithProvenance())
+
+	var b bytes.Buffer
+	require.NoError(t, report.Provenance().WriteCSV(&b))
+	rows, err := csv.NewReader(&b).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, rows, len(e.Ballots)+1)
+	assert.Equal(t, append(append([]string{"ballot", "weight", "preferences"}, e.CandidateNames...), "exhausted"), rows[0])
+	assert.Equal(t, "1", rows[1][0])
+}
+
+func TestProvenance_Disabled(t *testing.T) {
+	e := readBallots(t, filepath.Join(testdata, "election13.txt"))
+	report := meekstv.Count(e)
+	assert.Nil(t, report.Provenance())
+}
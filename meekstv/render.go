@@ -0,0 +1,270 @@
+package meekstv
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+// Order is the order in which candidates and transfers are rendered.
+type Order int
+
+const (
+	// ByIndex lists candidates in ballot file order.
+	ByIndex Order = iota
+	// ByAmount lists candidates by descending votes, ties in ballot file order.
+	ByAmount
+)
+
+type RenderOptions struct {
+	Order Order
+	// Precision is the number of decimals of votes, keep factors and thresholds.
+	Precision int
+}
+
+var DefaultRenderOptions = RenderOptions{Order: ByIndex, Precision: 2}
+
+// Renderer writes a human-readable report of a count.
+type Renderer interface {
+	Render(w io.Writer, l *Log) error
+}
+
+// Formats lists the names accepted by NewRenderer.
+var Formats = []string{"plain", "table", "markdown"}
+
+// NewRenderer returns the renderer of the given format: "plain" prints every round
+// one after the other, "table" prints the votes of all rounds side by side as OpaVote does,
+// and "markdown" prints every round as a Markdown section.
+func NewRenderer(format string, opts RenderOptions) (Renderer, error) {
+	switch format {
+	case "plain":
+		return &PlainRenderer{opts}, nil
+	case "table":
+		return &TableRenderer{opts}, nil
+	case "markdown":
+		return &MarkdownRenderer{opts}, nil
+	}
+	return nil, fmt.Errorf("unknown format %q, expected one of %s", format, strings.Join(Formats, ", "))
+}
+
+type PlainRenderer struct {
+	RenderOptions
+}
+
+func (r *PlainRenderer) Render(w io.Writer, l *Log) error {
+	p := &printer{w: w, precision: r.Precision}
+
+	for _, e := range l.entries {
+		p.printf("Round %d:\n", e.Round)
+		p.printf("Threshold: %s (%s)\n", p.num(e.Threshold), p.percent(e.Threshold, e.TotVotes))
+		p.printf("Exhausted: %s\n", p.num(e.Exhausted))
+
+		// the candidate votes in a table containing the name, keep factor and votes
+		p.printf("candidate\tkeep\tvotes\n")
+		for _, c := range sortedCandidates(e.CandidateSnapshot, r.Order) {
+			p.printf("%s\t%s\t%s\n", c.Name, p.num(c.KeepFactor), p.num(c.Votes))
+		}
+
+		// transfer breakdowns
+		if len(e.SurplusReceived) > 0 {
+			p.printf("surplus transfers:\n")
+			p.plainTransfers(e.SurplusReceived, e.SurplusExhaustedDelta, e.CandidateSnapshot, r.Order)
+		}
+		if len(e.EliminationReceived) > 0 {
+			p.printf("elimination transfers:\n")
+			p.plainTransfers(e.EliminationReceived, e.EliminationExhaustedDelta, e.CandidateSnapshot, r.Order)
+		}
+
+		for _, elected := range e.Elected {
+			p.printf("Elected: %s with %s votes\n", elected.Name, p.num(elected.Votes))
+		}
+		for _, defeated := range e.Defeated {
+			p.printf("Eliminated: %s\n", defeated.Name)
+		}
+		p.printf("-------------------------\n")
+	}
+	return p.err
+}
+
+func (p *printer) plainTransfers(received map[int]float64, exhausted float64, snapshot []Candidate, order Order) {
+	for _, t := range sortedTransfers(received, snapshot, order) {
+		p.printf("  -> %s: %s\n", t.Name, p.num(t.Votes))
+	}
+	if exhausted > 0 {
+		p.printf("  -> exhausted: %s\n", p.num(exhausted))
+	}
+}
+
+// TableRenderer prints one row per candidate and one column per round, followed by
+// the exhausted and total votes and the threshold. A candidate elected at the end of
+// a round is marked with "E", a defeated one with "D".
+type TableRenderer struct {
+	RenderOptions
+}
+
+func (r *TableRenderer) Render(w io.Writer, l *Log) error {
+	if len(l.entries) == 0 {
+		return nil
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	p := &printer{w: tw, precision: r.Precision}
+
+	p.printf("Candidate\t")
+	for _, e := range l.entries {
+		p.printf("Round %d\t", e.Round)
+	}
+	p.printf("\n")
+
+	// rows follow the final round, so that ordering by amount ranks the winners first
+	for _, c := range sortedCandidates(l.Results(), r.Order) {
+		p.printf("%s\t", c.Name)
+		for _, e := range l.entries {
+			p.printf("%s%s\t", p.num(e.CandidateSnapshot[c.Index].Votes), marker(e, c.Index))
+		}
+		p.printf("\n")
+	}
+
+	rows := []struct {
+		name  string
+		value func(*LogEntry) float64
+	}{
+		{"Exhausted", func(e *LogEntry) float64 { return e.Exhausted }},
+		{"Total", func(e *LogEntry) float64 { return e.TotVotes }},
+		{"Threshold", func(e *LogEntry) float64 { return e.Threshold }},
+	}
+	for _, row := range rows {
+		p.printf("%s\t", row.name)
+		for _, e := range l.entries {
+			p.printf("%s  \t", p.num(row.value(e)))
+		}
+		p.printf("\n")
+	}
+	if p.err != nil {
+		return p.err
+	}
+	return tw.Flush()
+}
+
+func marker(e *LogEntry, idx int) string {
+	for _, c := range e.Elected {
+		if c.Index == idx {
+			return " E"
+		}
+	}
+	for _, c := range e.Defeated {
+		if c.Index == idx {
+			return " D"
+		}
+	}
+	return "  "
+}
+
+// MarkdownRenderer prints every round as a section with a table of the candidates.
+type MarkdownRenderer struct {
+	RenderOptions
+}
+
+func (r *MarkdownRenderer) Render(w io.Writer, l *Log) error {
+	p := &printer{w: w, precision: r.Precision}
+
+	for _, e := range l.entries {
+		p.printf("## Round %d\n\n", e.Round)
+		p.printf("Threshold: %s (%s), exhausted: %s\n\n", p.num(e.Threshold), p.percent(e.Threshold, e.TotVotes), p.num(e.Exhausted))
+
+		p.printf("| Candidate | Keep factor | Votes |\n")
+		p.printf("| --- | ---: | ---: |\n")
+		for _, c := range sortedCandidates(e.CandidateSnapshot, r.Order) {
+			p.printf("| %s | %s | %s |\n", escapeMarkdown(c.Name), p.num(c.KeepFactor), p.num(c.Votes))
+		}
+		p.printf("\n")
+
+		if len(e.SurplusReceived) > 0 {
+			p.printf("Surplus transfers:\n\n")
+			p.markdownTransfers(e.SurplusReceived, e.SurplusExhaustedDelta, e.CandidateSnapshot, r.Order)
+		}
+		if len(e.EliminationReceived) > 0 {
+			p.printf("Elimination transfers:\n\n")
+			p.markdownTransfers(e.EliminationReceived, e.EliminationExhaustedDelta, e.CandidateSnapshot, r.Order)
+		}
+
+		for _, elected := range e.Elected {
+			p.printf("**Elected: %s** with %s votes\n\n", escapeMarkdown(elected.Name), p.num(elected.Votes))
+		}
+		for _, defeated := range e.Defeated {
+			p.printf("Eliminated: %s\n\n", escapeMarkdown(defeated.Name))
+		}
+	}
+	return p.err
+}
+
+func (p *printer) markdownTransfers(received map[int]float64, exhausted float64, snapshot []Candidate, order Order) {
+	for _, t := range sortedTransfers(received, snapshot, order) {
+		p.printf("- %s: %s\n", escapeMarkdown(t.Name), p.num(t.Votes))
+	}
+	if exhausted > 0 {
+		p.printf("- exhausted: %s\n", p.num(exhausted))
+	}
+	p.printf("\n")
+}
+
+var markdownEscaper = strings.NewReplacer(`\`, `\\`, `|`, `\|`, `*`, `\*`, `_`, `\_`)
+
+func escapeMarkdown(s string) string {
+	return markdownEscaper.Replace(s)
+}
+
+// printer formats numbers with a fixed precision and keeps the first write error.
+type printer struct {
+	w         io.Writer
+	precision int
+	err       error
+}
+
+func (p *printer) printf(format string, args ...any) {
+	if p.err != nil {
+		return
+	}
+	_, p.err = fmt.Fprintf(p.w, format, args...)
+}
+
+func (p *printer) num(f float64) string {
+	return fmt.Sprintf("%.*f", p.precision, f)
+}
+
+func (p *printer) percent(part, total float64) string {
+	if total == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%.*f%%", p.precision, part/total*100)
+}
+
+func sortedCandidates(snapshot []Candidate, order Order) []Candidate {
+	out := append([]Candidate(nil), snapshot...)
+	sort.SliceStable(out, func(i, j int) bool {
+		if order == ByAmount && out[i].Votes != out[j].Votes {
+			return out[i].Votes > out[j].Votes
+		}
+		return out[i].Index < out[j].Index
+	})
+	return out
+}
+
+func sortedTransfers(received map[int]float64, snapshot []Candidate, order Order) []TransferDocument {
+	out := make([]TransferDocument, 0, len(received))
+	for idx, amt := range received {
+		out = append(out, TransferDocument{
+			Candidate: idx,
+			Name:      nameByIndex(snapshot, idx),
+			Votes:     amt,
+		})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if order == ByAmount && out[i].Votes != out[j].Votes {
+			return out[i].Votes > out[j].Votes
+		}
+		return out[i].Candidate < out[j].Candidate
+	})
+	return out
+}
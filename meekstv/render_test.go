@@ -0,0 +1,95 @@
+package meekstv_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/linuxfoundation-it/meek-stv/meekstv"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func render(t *testing.T, format string, opts meekstv.RenderOptions, l *meekstv.Log) string {
+	t.Helper()
+	r, err := meekstv.NewRenderer(format, opts)
+	require.NoError(t, err)
+
+	var b bytes.Buffer
+	require.NoError(t, r.Render(&b, l))
+	return b.String()
+}
+
+func TestRender_Deterministic(t *testing.T) {
+	e := readBallots(t, "../testdata/election12.txt")
+	report := meekstv.Count(e)
+
+	for _, format := range meekstv.Formats {
+		t.Run(format, func(t *testing.T) {
+			for _, order := range []meekstv.Order{meekstv.ByIndex, meekstv.ByAmount} {
+				opts := meekstv.RenderOptions{Order: order, Precision: 4}
+				want := render(t, format, opts, &report)
+				for i := 0; i < 20; i++ {
+					require.Equal(t, want, render(t, format, opts, &report))
+				}
+			}
+		})
+	}
+}
+
+func TestRender_PlainMatchesPrintString(t *testing.T) {
+	e := readBallots(t, "../testdata/election13.txt")
+	report := meekstv.Count(e)
+
+	assert.Equal(t, report.PrintString(), render(t, "plain", meekstv.DefaultRenderOptions, &report))
+}
+
+func TestRender_Order(t *testing.T) {
+	e := readBallots(t, "../testdata/election12.txt")
+	report := meekstv.Count(e)
+
+	// Tschallacka is eliminated in the first round, Makyen receives the most and Yvette is withdrawn
+	out := render(t, "plain", meekstv.RenderOptions{Order: meekstv.ByIndex, Precision: 2}, &report)
+	transfers := out[strings.Index(out, "elimination transfers:"):]
+	assert.Less(t, strings.Index(transfers, "Machavity"), strings.Index(transfers, "Makyen"))
+
+	out = render(t, "plain", meekstv.RenderOptions{Order: meekstv.ByAmount, Precision: 2}, &report)
+	transfers = out[strings.Index(out, "elimination transfers:"):]
+	assert.Less(t, strings.Index(transfers, "Makyen"), strings.Index(transfers, "Machavity"))
+	assert.Contains(t, transfers, "  -> Makyen: 531.00\n")
+}
+
+func TestRender_Precision(t *testing.T) {
+	e := readBallots(t, "../testdata/election13.txt")
+	report := meekstv.Count(e)
+
+	assert.Contains(t, render(t, "plain", meekstv.RenderOptions{Precision: 0}, &report), "Threshold: 9318 (33%)\n")
+	assert.Contains(t, render(t, "plain", meekstv.RenderOptions{Precision: 4}, &report), "Threshold: 9318.3333 (33.3333%)\n")
+}
+
+func TestRender_Table(t *testing.T) {
+	e := readBallots(t, "../testdata/election13.txt")
+	report := meekstv.Count(e)
+
+	out := render(t, "table", meekstv.DefaultRenderOptions, &report)
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	// a header, one row per candidate, exhausted, total and threshold
+	require.Len(t, lines, 1+e.Candidates+3)
+	assert.Contains(t, lines[0], "Round 2")
+	assert.Contains(t, out, "9208.00 E")
+}
+
+func TestRender_Markdown(t *testing.T) {
+	e := readBallots(t, "../testdata/election13.txt")
+	e.CandidateNames[0] = "Zoe | *mod*"
+	report := meekstv.Count(e)
+
+	out := render(t, "markdown", meekstv.DefaultRenderOptions, &report)
+	assert.Contains(t, out, "## Round 0\n")
+	assert.Contains(t, out, `| Zoe \| \*mod\* | 1.00 | 7236.00 |`)
+}
+
+func TestNewRenderer_Unknown(t *testing.T) {
+	_, err := meekstv.NewRenderer("pdf", meekstv.DefaultRenderOptions)
+	assert.Error(t, err)
+}
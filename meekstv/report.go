@@ -2,11 +2,26 @@ package meekstv
 
 import (
 	"fmt"
+	"os"
 	"strings"
 )
 
 type Log struct {
-	entries []*LogEntry
+	entries    []*LogEntry
+	provenance *Provenance
+	err        error
+}
+
+// Err returns the error that stopped the count before it completed, if any: that of the
+// context of WithContext. The rounds of the log are then those counted before it stopped.
+func (l *Log) Err() error {
+	return l.err
+}
+
+// Provenance returns the split of every ballot among the candidates at the end of the count,
+// or nil if the count didn't run with WithProvenance.
+func (l *Log) Provenance() *Provenance {
+	return l.provenance
 }
 
 func (l *Log) NumRounds() int {
@@ -14,17 +29,25 @@ func (l *Log) NumRounds() int {
 }
 
 func (l *Log) Round(i int) *LogEntry {
-	if len(l.entries) < i {
+	if i < 0 || i >= len(l.entries) {
 		panic(fmt.Errorf("count didn't reach round %d", i))
 	}
 	return l.entries[i]
 }
 
+// Results returns the candidates at the end of the count, or nil if it stopped before its first round.
 func (l *Log) Results() []Candidate {
+	if len(l.entries) == 0 {
+		return nil
+	}
 	return l.last().CandidateSnapshot
 }
 
+// Winners returns the indices of the winners, or nil if the count stopped before its first round.
 func (l *Log) Winners() []int {
+	if len(l.entries) == 0 {
+		return nil
+	}
 	out := make([]int, 0)
 	for _, c := range l.Results() {
 		if c.State == Elected {
@@ -34,6 +57,25 @@ func (l *Log) Winners() []int {
 	return out
 }
 
+// Winner is a winner of the count, as the APIs report it.
+type Winner struct {
+	Index int    `json:"index"`
+	Name  string `json:"name"`
+	// Class is the seat class of the seat the winner fills, omitted for open seats
+	Class string `json:"class,omitempty"`
+}
+
+// Elected returns the winners of the count, by index, with their names and seat classes.
+func (l *Log) Elected() []Winner {
+	out := make([]Winner, 0)
+	for _, c := range l.Results() {
+		if c.State == Elected {
+			out = append(out, Winner{Index: c.Index, Name: c.Name, Class: c.Class})
+		}
+	}
+	return out
+}
+
 func nameByIndex(snapshot []Candidate, idx int) string {
 	for i := range snapshot {
 		if snapshot[i].Index == idx {
@@ -43,97 +85,16 @@ func nameByIndex(snapshot []Candidate, idx int) string {
 	return fmt.Sprintf("candidate#%d", idx)
 }
 
+// Print prints the rounds of the count to standard output with the default plain renderer.
 func (l *Log) Print() {
-	for _, e := range l.entries {
-		fmt.Println("round", e.Round)
-		fmt.Printf("threshold %.02f (%.02f%%)\n", e.Threshold, e.Threshold/e.TotVotes*100)
-		fmt.Printf("exhausted: %.02f\n", e.Exhausted)
-
-		// print the candidate votes in a table containing the name, keep factor and votes
-		// for every round
-		fmt.Println("candidate\tkeep\tvotes")
-		for _, c := range e.CandidateSnapshot {
-			fmt.Printf("%s\t%.02f\t%.02f\n", c.Name, c.KeepFactor, c.Votes)
-		}
-
-		// Print transfer breakdowns, if any
-		if len(e.SurplusReceived) > 0 {
-			fmt.Println("surplus transfers:")
-			for idx, amt := range e.SurplusReceived {
-				name := nameByIndex(e.CandidateSnapshot, idx)
-				fmt.Printf("  -> %s: %.02f\n", name, amt)
-			}
-			if e.SurplusExhaustedDelta > 0 {
-				fmt.Printf("  -> exhausted: %.02f\n", e.SurplusExhaustedDelta)
-			}
-		}
-		if len(e.EliminationReceived) > 0 {
-			fmt.Println("elimination transfers:")
-			for idx, amt := range e.EliminationReceived {
-				name := nameByIndex(e.CandidateSnapshot, idx)
-				fmt.Printf("  -> %s: %.02f\n", name, amt)
-			}
-			if e.EliminationExhaustedDelta > 0 {
-				fmt.Printf("  -> exhausted: %.02f\n", e.EliminationExhaustedDelta)
-			}
-		}
-
-		for _, elected := range e.Elected {
-			fmt.Printf("elected %s with %.02f votes\n", elected.Name, elected.Votes)
-		}
-		for _, defeated := range e.Defeated {
-			fmt.Println("eliminating", defeated.Name)
-		}
-		fmt.Println("-------------------------")
-	}
+	_ = (&PlainRenderer{DefaultRenderOptions}).Render(os.Stdout, l)
 }
 
+// PrintString returns the rounds of the count as printed by Print.
 func (l *Log) PrintString() string {
-	var result strings.Builder
-
-	for _, e := range l.entries {
-		result.WriteString(fmt.Sprintf("Round %d:\n", e.Round))
-		result.WriteString(fmt.Sprintf("Threshold: %.02f (%.02f%%)\n", e.Threshold, e.Threshold/e.TotVotes*100))
-		result.WriteString(fmt.Sprintf("Exhausted: %.02f\n", e.Exhausted))
-
-		// print the candidate votes in a table containing the name, keep factor and votes
-		result.WriteString("candidate\tkeep\tvotes\n")
-		for _, c := range e.CandidateSnapshot {
-			result.WriteString(fmt.Sprintf("%s\t%.02f\t%.02f\n", c.Name, c.KeepFactor, c.Votes))
-		}
-
-		// transfer breakdowns
-		if len(e.SurplusReceived) > 0 {
-			result.WriteString("surplus transfers:\n")
-			for idx, amt := range e.SurplusReceived {
-				name := nameByIndex(e.CandidateSnapshot, idx)
-				result.WriteString(fmt.Sprintf("  -> %s: %.02f\n", name, amt))
-			}
-			if e.SurplusExhaustedDelta > 0 {
-				result.WriteString(fmt.Sprintf("  -> exhausted: %.02f\n", e.SurplusExhaustedDelta))
-			}
-		}
-		if len(e.EliminationReceived) > 0 {
-			result.WriteString("elimination transfers:\n")
-			for idx, amt := range e.EliminationReceived {
-				name := nameByIndex(e.CandidateSnapshot, idx)
-				result.WriteString(fmt.Sprintf("  -> %s: %.02f\n", name, amt))
-			}
-			if e.EliminationExhaustedDelta > 0 {
-				result.WriteString(fmt.Sprintf("  -> exhausted: %.02f\n", e.EliminationExhaustedDelta))
-			}
-		}
-
-		for _, elected := range e.Elected {
-			result.WriteString(fmt.Sprintf("Elected: %s with %.02f votes\n", elected.Name, elected.Votes))
-		}
-		for _, defeated := range e.Defeated {
-			result.WriteString(fmt.Sprintf("Eliminated: %s\n", defeated.Name))
-		}
-		result.WriteString("-------------------------\n")
-	}
-
-	return result.String()
+	var b strings.Builder
+	_ = (&PlainRenderer{DefaultRenderOptions}).Render(&b, l)
+	return b.String()
 }
 
 func (l *Log) add(round int) {
@@ -152,11 +113,19 @@ type LogEntry struct {
 	Elected           []Candidate
 	Defeated          []Candidate
 	Exhausted         float64
-
-	// Transfer breakdowns realized in this round compared to previous round's event
-	// If the previous round elected candidate(s), SurplusReceived shows how much each candidate gained
-	// due to surplus redistribution. If the previous round eliminated a candidate, EliminationReceived
-	// shows how much each candidate gained from that elimination.
+	exhausted         votes
+	// ExhaustedBreakdown tells why votes exhausted, and how many ballots did.
+	ExhaustedBreakdown ExhaustedBreakdown
+
+	// Transfers is the matrix of the votes each candidate gave to every other candidate,
+	// or to exhausted, since the previous round. It is nil in the first round.
+	Transfers *TransferMatrix
+
+	// Transfer breakdowns realized in this round compared to previous round's event, taken from Transfers.
+	// If the previous round elected candidate(s), SurplusReceived shows how much each other candidate received
+	// from their surpluses. If the previous round eliminated a candidate, EliminationReceived
+	// shows how much each candidate received from that elimination. The exhausted deltas are the votes
+	// of the elected or eliminated candidates that exhausted.
 	SurplusReceived           map[int]float64
 	EliminationReceived       map[int]float64
 	SurplusExhaustedDelta     float64
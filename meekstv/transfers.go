@@ -0,0 +1,288 @@
+package meekstv
+
+import "github.com/linuxfoundation-it/meek-stv/election"
+
+// TransferMatrix holds the votes moved between a round and the previous one,
+// counted exactly in the units of the count. At(s, r) is the number of votes candidate s
+// gave to candidate r. The last row and column, at index Exhausted(), stand for exhausted votes.
+//
+// For every candidate, the votes received minus the votes given equal its change
+// in votes between the two rounds, and the votes received by exhausted equal the
+// change in exhausted votes.
+type TransferMatrix struct {
+	scale scale
+	votes [][]votes
+}
+
+func newTransferMatrix(candidates int, s scale) *TransferMatrix {
+	m := &TransferMatrix{scale: s, votes: make([][]votes, candidates+1)}
+	for i := range m.votes {
+		m.votes[i] = make([]votes, candidates+1)
+	}
+	return m
+}
+
+// Exhausted returns the index of exhausted votes in the rows and columns of the matrix.
+func (m *TransferMatrix) Exhausted() int {
+	return len(m.votes) - 1
+}
+
+// At returns the votes candidate s gave to candidate r, or to exhausted.
+func (m *TransferMatrix) At(s, r int) float64 {
+	return m.scale.float64(m.votes[s][r])
+}
+
+// Outflow returns the votes given away by candidate s.
+func (m *TransferMatrix) Outflow(s int) float64 {
+	return m.scale.float64(m.outflow(s))
+}
+
+// Inflow returns the votes received by candidate r, or by exhausted.
+func (m *TransferMatrix) Inflow(r int) float64 {
+	return m.scale.float64(m.inflow(r))
+}
+
+func (m *TransferMatrix) outflow(s int) votes {
+	var sum votes
+	for _, v := range m.votes[s] {
+		sum = sum.add(v)
+	}
+	return sum
+}
+
+func (m *TransferMatrix) inflow(r int) votes {
+	var sum votes
+	for s := range m.votes {
+		sum = sum.add(m.votes[s][r])
+	}
+	return sum
+}
+
+// float64s returns the rows of the matrix in float64, for reports
+func (m *TransferMatrix) float64s() [][]float64 {
+	out := make([][]float64, len(m.votes))
+	for s, row := range m.votes {
+		out[s] = make([]float64, len(row))
+		for r, v := range row {
+			out[s][r] = m.scale.float64(v)
+		}
+	}
+	return out
+}
+
+func (m *TransferMatrix) add(s, r int, v votes) {
+	m.votes[s][r] = m.votes[s][r].add(v)
+}
+
+// received returns the votes each other candidate received from sources,
+// and the votes of sources that exhausted
+func (m *TransferMatrix) received(sources []Candidate) (map[int]float64, float64) {
+	isSource := make(map[int]bool, len(sources))
+	for _, s := range sources {
+		isSource[s.Index] = true
+	}
+
+	sums := make(map[int]votes)
+	var exhausted votes
+	for _, s := range sources {
+		for r := 0; r < m.Exhausted(); r++ {
+			if v := m.votes[s.Index][r]; !isSource[r] && v.sign() > 0 {
+				sums[r] = sums[r].add(v)
+			}
+		}
+		exhausted = exhausted.add(m.votes[s.Index][m.Exhausted()])
+	}
+	out := make(map[int]float64, len(sums))
+	for r, v := range sums {
+		out[r] = m.scale.float64(v)
+	}
+	return out, m.scale.float64(exhausted)
+}
+
+// take gives candidate r the votes t it keeps of the weight flowing down a ballot: every source
+// gives its weight times kf divided by n, rounded down, and the last one the rest of t.
+// It returns the weight that flows on.
+func (m *TransferMatrix) take(flowing []transfer, r int, t votes, kf keepFactor, n int64) []transfer {
+	for i := range flowing {
+		v := t
+		if i < len(flowing)-1 {
+			v = flowing[i].weight.keep(kf, false).div(n, false)
+		}
+		m.add(flowing[i].source, r, v)
+		flowing[i].weight = flowing[i].weight.sub(v)
+		t = t.sub(v)
+	}
+	return flowing
+}
+
+// transfer is the weight released by a source candidate and still flowing down a ballot
+type transfer struct {
+	source int
+	weight votes
+}
+
+// distribute adds the weight of the ballots to the votes of the candidates with their
+// current keep factors, and returns the weight that exhausted, and why it did.
+//
+// The votes are counted exactly, in the units of s, from the weights of the ballots in them:
+// a candidate keeps its keep factor of the weight reaching it rounded up to a unit, as the Meek
+// rules do, and candidates ranked equally their shares rounded down, so that no ballot gives
+// away more than its weight.
+//
+// When prev holds the keep factors the ballots were distributed with in the previous round,
+// every ballot is also walked with them, and the votes that changed hands are returned
+// as a transfer matrix: what a candidate keeps less of a ballot is followed down the ballot,
+// and split among the candidates after it as they keep it, the rest exhausting.
+// Every candidate whose keep factor changed is a separate source, so that the surpluses of
+// candidates elected together are told apart. The matrix is counted from the same amounts
+// as the votes, so that it accounts for every unit that moved.
+//
+// The weight reaching candidates ranked equally is split evenly among those of them that
+// are continuing, each keeping its share times its keep factor, and the rest moves on together.
+func distribute(input *election.Election, weights []votes, s scale, cs Candidates, prev []keepFactor) (votes, ExhaustedBreakdown, *TransferMatrix) {
+	var m *TransferMatrix
+	if prev != nil {
+		m = newTransferMatrix(len(cs), s)
+	}
+
+	var exhausted votes
+	var breakdown ExhaustedBreakdown
+	var flowing []transfer
+	for i, bl := range input.Ballots {
+		w := weights[i]
+		wPrev := w
+		active := false
+		flowing = flowing[:0]
+		for k := 0; k < len(bl.Preferences); k++ {
+			if w.sign() <= 0 && (m == nil || wPrev.sign() <= 0) {
+				break
+			}
+			if end := bl.GroupEnd(k); end > k+1 {
+				g := &group{candidates: bl.Preferences[k:end]}
+				w, wPrev, flowing = g.distribute(cs, w, prev, wPrev, flowing, m)
+				active = active || g.current > 0
+				k = end - 1
+				continue
+			}
+
+			p := bl.Preferences[k]
+			c := cs[p]
+			v := w.keep(c.keep, true)
+
+			if m != nil {
+				// of the weight c had in the previous round, it now keeps own, and releases the rest of what it
+				// kept then; the rest of v is its share of the weight released before it on the ballot
+				before, own := wPrev.keep(prev[p], true), wPrev.keep(c.keep, true)
+				flowing = m.take(flowing, p, v.sub(own), c.keep, 1)
+				if released := before.sub(own); released.sign() != 0 {
+					flowing = append(flowing, transfer{source: p, weight: released})
+				}
+				wPrev = wPrev.sub(before)
+			}
+
+			c.votes = c.votes.add(v)
+			w = w.sub(v)
+			active = active || c.keep > 0
+		}
+		if w.sign() > 0 {
+			exhausted = exhausted.add(w)
+			breakdown.add(bl, s.float64(w), active, input.Withdrawn)
+		}
+		for _, t := range flowing {
+			m.add(t.source, m.Exhausted(), t.weight)
+		}
+	}
+	return exhausted, breakdown, m
+}
+
+// group is a group of candidates ranked equally on a ballot
+type group struct {
+	candidates []int
+	// number of continuing candidates, with a keep factor above 0, in the current and previous rounds
+	current, previous int
+}
+
+// keeps returns the votes a candidate with keep factor kf keeps of the weight w reaching the group
+func (g *group) keeps(w votes, kf keepFactor, continuing int) votes {
+	if kf <= 0 {
+		return votes{}
+	}
+	return w.keep(kf, false).div(int64(continuing), false)
+}
+
+// distribute adds the votes the group keeps of weight w, rounded down, and returns the weight that moves on.
+// When m is not nil, it also follows the weight wPrev the group had in the previous round,
+// and the weight flowing from earlier sources, as distribute does for a single candidate:
+// what the candidates of the group whose keep factor changed keep less of is first given to the
+// others, which keep more of it when one of them is defeated, and the rest moves on.
+func (g *group) distribute(cs Candidates, w votes, prev []keepFactor, wPrev votes, flowing []transfer, m *TransferMatrix) (votes, votes, []transfer) {
+	for _, p := range g.candidates {
+		if cs[p].keep > 0 {
+			g.current++
+		}
+		if m != nil && prev[p] > 0 {
+			g.previous++
+		}
+	}
+
+	var kept votes
+	for _, p := range g.candidates {
+		v := g.keeps(w, cs[p].keep, g.current)
+		cs[p].votes = cs[p].votes.add(v)
+		kept = kept.add(v)
+	}
+	if m == nil {
+		return w.sub(kept), wPrev, flowing
+	}
+
+	// change is what each candidate keeps more of the weight of the previous round, and gain that of
+	// the candidates whose keep factor didn't change, which those whose keep factor changed give them
+	// in proportion to what they keep less. The rest of what these keep less moves on; it is negative
+	// when the others gain the units that shares rounded down used to leave to the rest of the ballot.
+	change := make([]votes, len(g.candidates))
+	var keptBefore, gain, released votes
+	var sources []int
+	for i, p := range g.candidates {
+		before, own := g.keeps(wPrev, prev[p], g.previous), g.keeps(wPrev, cs[p].keep, g.current)
+		flowing = m.take(flowing, p, g.keeps(w, cs[p].keep, g.current).sub(own), cs[p].keep, int64(max(g.current, 1)))
+		keptBefore = keptBefore.add(before)
+		change[i] = own.sub(before)
+		if cs[p].keep == prev[p] {
+			gain = gain.add(change[i])
+		} else {
+			sources = append(sources, i)
+			if change[i].sign() < 0 {
+				released = released.add(change[i].neg())
+			}
+		}
+	}
+
+	left := gain
+	for n, i := range sources {
+		s := g.candidates[i]
+		give := left
+		if n < len(sources)-1 {
+			give = votes{}
+			if released.sign() > 0 && change[i].sign() < 0 {
+				give = gain.mulDiv(change[i].neg(), released)
+			}
+		}
+		left = left.sub(give)
+		if rest := change[i].neg().sub(give); rest.sign() != 0 {
+			flowing = append(flowing, transfer{source: s, weight: rest})
+		}
+		for j, p := range g.candidates {
+			if cs[p].keep != prev[p] || change[j].sign() <= 0 || give.sign() <= 0 {
+				continue
+			}
+			v := give
+			if change[j].cmp(v) < 0 {
+				v = change[j]
+			}
+			m.add(s, p, v)
+			change[j] = change[j].sub(v)
+			give = give.sub(v)
+		}
+	}
+	return w.sub(kept), wPrev.sub(keptBefore), flowing
+}
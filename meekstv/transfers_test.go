@@ -1,35 +1,15 @@
 package meekstv
 
 import (
-	"encoding/json"
 	"math"
 	"os"
 	"path/filepath"
-	"sort"
+	"strings"
 	"testing"
 
 	"github.com/linuxfoundation-it/meek-stv/election"
 )
 
-type expectedJSON struct {
-	Winners []int  `json:"winners"`
-	Title   string `json:"title"`
-}
-
-func readJSONExpect(t *testing.T, jsonPath string) expectedJSON {
-	t.Helper()
-	f, err := os.Open(jsonPath)
-	if err != nil {
-		t.Fatalf("open json: %v", err)
-	}
-	defer f.Close()
-	var exp expectedJSON
-	if err := json.NewDecoder(f).Decode(&exp); err != nil {
-		t.Fatalf("decode json: %v", err)
-	}
-	return exp
-}
-
 func readElectionTxt(t *testing.T, txtPath string) *election.Election {
 	t.Helper()
 	f, err := os.Open(txtPath)
@@ -37,57 +17,31 @@ func readElectionTxt(t *testing.T, txtPath string) *election.Election {
 		t.Fatalf("open txt: %v", err)
 	}
 	defer f.Close()
-	return election.Read(f)
+	e, err := election.Parse(f)
+	if err != nil {
+		t.Fatalf("parse txt: %v", err)
+	}
+	e.Unquote()
+	return e
 }
 
 func floatAlmostEqual(a, b, tol float64) bool {
 	return math.Abs(a-b) <= tol
 }
 
-func asSortedCopy(ints []int) []int {
-	out := append([]int(nil), ints...)
-	sort.Ints(out)
-	return out
-}
-
-func TestElection10_WinnersAndTransfers(t *testing.T) {
-	base := filepath.Join("..", "testdata")
-	txt := filepath.Join(base, "election10.txt")
-	jsonPath := filepath.Join(base, "election10.json")
-
-	params := readElectionTxt(t, txt)
-	report := Count(params)
-
-	exp := readJSONExpect(t, jsonPath)
-
-	// winners match
-	gotWinners := report.Winners()
-	if !slicesEqual(asSortedCopy(gotWinners), asSortedCopy(exp.Winners)) {
-		t.Fatalf("winners mismatch: got %v, want %v", gotWinners, exp.Winners)
+func TestTransfers(t *testing.T) {
+	files, err := filepath.Glob(filepath.Join("..", "testdata", "*.txt"))
+	if err != nil {
+		t.Fatal(err)
 	}
+	for _, txt := range files {
+		t.Run(strings.TrimSuffix(filepath.Base(txt), ".txt"), func(t *testing.T) {
+			report := Count(readElectionTxt(t, txt))
 
-	// transfer conservation per round
-	checkTransferConservation(t, &report)
-}
-
-func TestElection11_WinnersAndTransfers(t *testing.T) {
-	base := filepath.Join("..", "testdata")
-	txt := filepath.Join(base, "election11.txt")
-	jsonPath := filepath.Join(base, "election11.json")
-
-	params := readElectionTxt(t, txt)
-	report := Count(params)
-
-	exp := readJSONExpect(t, jsonPath)
-
-	// winners match
-	gotWinners := report.Winners()
-	if !slicesEqual(asSortedCopy(gotWinners), asSortedCopy(exp.Winners)) {
-		t.Fatalf("winners mismatch: got %v, want %v", gotWinners, exp.Winners)
+			// transfer conservation per round
+			checkTransferConservation(t, &report)
+		})
 	}
-
-	// transfer conservation per round
-	checkTransferConservation(t, &report)
 }
 
 func checkTransferConservation(t *testing.T, report *Log) {
@@ -140,14 +94,83 @@ func checkTransferConservation(t *testing.T, report *Log) {
 	}
 }
 
-func slicesEqual(a, b []int) bool {
-	if len(a) != len(b) {
-		return false
+func TestTransferMatrix(t *testing.T) {
+	files, err := filepath.Glob(filepath.Join("..", "testdata", "*.txt"))
+	if err != nil {
+		t.Fatal(err)
 	}
-	for i := range a {
-		if a[i] != b[i] {
-			return false
+	for _, txt := range files {
+		t.Run(strings.TrimSuffix(filepath.Base(txt), ".txt"), func(t *testing.T) {
+			report := Count(readElectionTxt(t, txt))
+			checkTransferMatrix(t, &report)
+		})
+	}
+}
+
+// checkTransferMatrix checks that the rows and columns of the transfer matrices
+// reconcile exactly with the changes in votes and exhausted votes
+func checkTransferMatrix(t *testing.T, report *Log) {
+	t.Helper()
+	for i := 1; i < len(report.entries); i++ {
+		prev, cur := report.entries[i-1], report.entries[i]
+		m := cur.Transfers
+		if m == nil {
+			t.Fatalf("round %d has no transfer matrix", cur.Round)
+		}
+		for c := range cur.CandidateSnapshot {
+			delta := cur.CandidateSnapshot[c].votes.sub(prev.CandidateSnapshot[c].votes)
+			if got := m.inflow(c).sub(m.outflow(c)); got.cmp(delta) != 0 {
+				t.Fatalf("round %d candidate %d: received minus given is %v, votes changed by %v", cur.Round, c, got.big(), delta.big())
+			}
 		}
+		delta := cur.exhausted.sub(prev.exhausted)
+		if got := m.inflow(m.Exhausted()); got.cmp(delta) != 0 {
+			t.Fatalf("round %d: %v votes exhausted, exhausted votes changed by %v", cur.Round, got.big(), delta.big())
+		}
+		// only the candidates elected or defeated in the previous round give votes away
+		for s := range cur.CandidateSnapshot {
+			event := false
+			for _, c := range append(append([]Candidate(nil), prev.Elected...), prev.Defeated...) {
+				event = event || c.Index == s
+			}
+			if !event && m.outflow(s).sign() != 0 {
+				t.Fatalf("round %d: candidate %d gave %f votes", cur.Round, s, m.Outflow(s))
+			}
+		}
+	}
+}
+
+func TestTransferMatrix_SimultaneousSurpluses(t *testing.T) {
+	// A and B are elected together in the first round; A's surplus goes to C, B's to D
+	e := &election.Election{
+		Title:          "Two surpluses",
+		Candidates:     5,
+		Seats:          3,
+		CandidateNames: []string{"A", "B", "C", "D", "E"},
+		Ballots: []election.Ballot{
+			{Weight: election.NewWeight(40), Preferences: []int{0, 2}},
+			{Weight: election.NewWeight(35), Preferences: []int{1, 3}},
+			{Weight: election.NewWeight(15), Preferences: []int{2}},
+			{Weight: election.NewWeight(12), Preferences: []int{3}},
+			{Weight: election.NewWeight(8), Preferences: []int{4}},
+		},
+	}
+	report := Count(e)
+	if len(report.entries) < 2 || len(report.entries[0].Elected) != 2 {
+		t.Fatalf("expected A and B to be elected in the first round")
+	}
+
+	// the whole surplus of each of them, what it keeps less of its ballots, goes to the next preference
+	first, second := report.entries[0].CandidateSnapshot, report.entries[1].CandidateSnapshot
+	m := report.entries[1].Transfers
+	surplusA, surplusB := first[0].votes.sub(second[0].votes), first[1].votes.sub(second[1].votes)
+	if m.votes[0][2].cmp(surplusA) != 0 || m.votes[0][3].sign() != 0 {
+		t.Errorf("A gave %f to C and %f to D, want %f and 0", m.At(0, 2), m.At(0, 3), m.scale.float64(surplusA))
+	}
+	if m.votes[1][3].cmp(surplusB) != 0 || m.votes[1][2].sign() != 0 {
+		t.Errorf("B gave %f to D and %f to C, want %f and 0", m.At(1, 3), m.At(1, 2), m.scale.float64(surplusB))
+	}
+	if got := report.entries[1].SurplusReceived; got[2] != m.scale.float64(surplusA) || got[3] != m.scale.float64(surplusB) {
+		t.Errorf("surplus received %v, want %f and %f", got, m.scale.float64(surplusA), m.scale.float64(surplusB))
 	}
-	return true
 }
@@ -0,0 +1,94 @@
+package meekstv_test
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/linuxfoundation-it/meek-stv/election"
+	"github.com/linuxfoundation-it/meek-stv/meekstv"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCount_FractionalWeights(t *testing.T) {
+	e := readBallots(t, filepath.Join(testdata, "election13.txt"))
+	want := meekstv.Count(e)
+
+	// halving every weight halves every tally, and changes nothing else
+	halved := *e
+	halved.Ballots = make([]election.Ballot, len(e.Ballots))
+	for i, b := range e.Ballots {
+		n, ok := b.Weight.Int()
+		require.True(t, ok)
+		w, err := election.ParseWeight(fmt.Sprintf("%d.%d", n/2, n%2*5))
+		require.NoError(t, err)
+		halved.Ballots[i] = election.Ballot{Weight: w, Preferences: b.Preferences}
+	}
+	assert.Equal(t, e.TotalWeight().Float64()/2, halved.TotalWeight().Float64())
+	got := meekstv.Count(&halved)
+
+	require.Equal(t, want.NumRounds(), got.NumRounds())
+	assert.Equal(t, want.Winners(), got.Winners())
+	for i := 0; i < want.NumRounds(); i++ {
+		w, g := want.Round(i), got.Round(i)
+		assert.Equal(t, w.Threshold/2, g.Threshold, "round %d", i)
+		assert.Equal(t, w.Exhausted/2, g.Exhausted, "round %d", i)
+		for c := range w.CandidateSnapshot {
+			assert.Equal(t, w.VotesOf(c)/2, g.VotesOf(c), "round %d candidate %d", i, c)
+		}
+	}
+}
+
+func TestCount_DecimalWeights(t *testing.T) {
+	// A has 0.1 + 0.2 votes and B 0.3, which float64 tells apart: the exact tie defeats A,
+	// the first of them, whose votes elect B
+	e := &election.Election{
+		Title:          "Decimal weights",
+		Candidates:     3,
+		Seats:          1,
+		CandidateNames: []string{"A", "B", "C"},
+		Ballots: []election.Ballot{
+			{Weight: mustWeight(t, "0.1"), Preferences: []int{0, 1}},
+			{Weight: mustWeight(t, "0.2"), Preferences: []int{0, 1}},
+			{Weight: mustWeight(t, "0.3"), Preferences: []int{1}},
+			{Weight: mustWeight(t, "0.5"), Preferences: []int{2}},
+		},
+	}
+	report := meekstv.Count(e)
+	first := report.Round(0)
+	assert.Equal(t, first.VotesOf(0), first.VotesOf(1))
+	assert.Equal(t, 0.55, first.Threshold)
+	assert.Zero(t, first.ExhaustedBreakdown.Rounding)
+	require.Len(t, first.Defeated, 1)
+	assert.Equal(t, "A", first.Defeated[0].Name)
+	assert.Equal(t, []int{1}, report.Winners())
+}
+
+func TestCount_LargeWeights(t *testing.T) {
+	// B has one vote more than C, which float64 can't tell beyond 2^53: C is defeated, and B elected
+	e := &election.Election{
+		Title:          "Large weights",
+		Candidates:     3,
+		Seats:          1,
+		CandidateNames: []string{"A", "B", "C"},
+		Ballots: []election.Ballot{
+			{Weight: election.NewWeight(1 << 54), Preferences: []int{0}},
+			{Weight: election.NewWeight(1<<53 + 1), Preferences: []int{1}},
+			{Weight: election.NewWeight(1 << 53), Preferences: []int{2, 1}},
+		},
+	}
+	report := meekstv.Count(e)
+	first := report.Round(0)
+	require.Len(t, first.Defeated, 1)
+	assert.Equal(t, "C", first.Defeated[0].Name)
+	assert.Equal(t, []int{1}, report.Winners())
+	assert.Equal(t, float64(1<<54), report.Round(1).VotesOf(1))
+}
+
+func mustWeight(t *testing.T, s string) election.Weight {
+	t.Helper()
+	w, err := election.ParseWeight(s)
+	require.NoError(t, err)
+	return w
+}
@@ -0,0 +1,153 @@
+package opavote
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/linuxfoundation-it/meek-stv/election"
+	"github.com/linuxfoundation-it/meek-stv/meekstv"
+)
+
+// DefaultPrecision is the number of decimals OpaVote counts with.
+const DefaultPrecision = 6
+
+// FromLog converts the log of a count of e into an OpaVote report,
+// with vote amounts scaled by 10^precision.
+func FromLog(l *meekstv.Log, e *election.Election, precision int) *Report {
+	scale := func(v float64) int64 {
+		return int64(math.Round(v * pow10(precision)))
+	}
+
+	// OpaVote counts whole ballots, fractional weights are rounded
+	nVotes := int(math.Round(e.TotalWeight().Float64()))
+	nValid := nVotes - int(math.Round(e.CountEmpty().Float64()))
+
+	r := &Report{
+		NSeats:     e.Seats,
+		NVotes:     nVotes,
+		Title:      e.Title,
+		Precision:  precision,
+		Withdrawn:  []int{},
+		Method:     "Meek STV",
+		Version:    "1.0",
+		Candidates: e.CandidateNames,
+		Winners:    l.Winners(),
+		TieBreaks:  []interface{}{},
+		Options: [][]interface{}{
+			{"prec", precision},
+			{"thresholdFormula", "Droop"},
+			{"dynamicThreshold", "Dynamic"},
+			{"fractionalThreshold", "Fractional"},
+		},
+		Rounds:      make([]Round, 0, l.NumRounds()),
+		NValidVotes: nValid,
+	}
+	for i := 0; i < e.Candidates; i++ {
+		if e.Withdrawn[i] {
+			r.Withdrawn = append(r.Withdrawn, i)
+		}
+	}
+
+	winners := map[int]bool{}
+	losers := map[int]bool{}
+	for i := 0; i < l.NumRounds(); i++ {
+		entry := l.Round(i)
+
+		// the action of a round is the event of the previous one,
+		// whose effects are counted in this round
+		var msg strings.Builder
+		action := Action{Type: "first", Desc: "Count of first choices. "}
+		if i == 0 {
+			msg.WriteString(action.Desc)
+		} else {
+			prev := l.Round(i - 1)
+			switch {
+			case len(prev.Defeated) > 0:
+				action = Action{Type: "eliminate", Desc: "All losing candidates are eliminated. "}
+				for _, c := range prev.Defeated {
+					losers[c.Index] = true
+					action.Candidates = append(action.Candidates, c.Index)
+				}
+				fmt.Fprintf(&msg, "Count after eliminating %s and transferring votes. %s", names(prev.Defeated), action.Desc)
+			case len(prev.Elected) > 0:
+				action = Action{Type: "surplus", Desc: ""}
+				var kfs []string
+				for _, c := range prev.CandidateSnapshot {
+					if winners[c.Index] && c.KeepFactor < 1 {
+						action.Candidates = append(action.Candidates, c.Index)
+						kfs = append(kfs, fmt.Sprintf("%s, %.*f", c.Name, precision, c.KeepFactor))
+					}
+				}
+				msg.WriteString("Count after transferring surplus votes. ")
+				if len(kfs) > 0 {
+					fmt.Fprintf(&msg, "Keep factors of candidates who have exceeded the threshold: %s. ", join(kfs))
+				}
+			}
+		}
+
+		surplus := 0.0
+		for _, c := range entry.Elected {
+			winners[c.Index] = true
+		}
+		for _, c := range entry.CandidateSnapshot {
+			if winners[c.Index] {
+				surplus += math.Max(c.Votes-entry.Threshold, 0)
+			}
+		}
+		switch len(entry.Elected) {
+		case 0:
+		case 1:
+			fmt.Fprintf(&msg, "Candidate %s has reached the threshold and is elected. ", entry.Elected[0].Name)
+		default:
+			fmt.Fprintf(&msg, "Candidates %s have reached the threshold and are elected. ", names(entry.Elected))
+		}
+
+		round := Round{
+			Count:      make([]int, len(entry.CandidateSnapshot)),
+			Surplus:    int(scale(surplus)),
+			Exhausted:  int(scale(entry.Exhausted)),
+			Continuing: []int{},
+			N:          i + 1,
+			Msg:        msg.String(),
+			Winners:    keys(winners),
+			Losers:     keys(losers),
+			Thresh:     scale(entry.Threshold),
+			Action:     action,
+		}
+		for _, c := range entry.CandidateSnapshot {
+			round.Count[c.Index] = int(scale(c.Votes))
+			if !winners[c.Index] && !losers[c.Index] && !e.Withdrawn[c.Index] {
+				round.Continuing = append(round.Continuing, c.Index)
+			}
+		}
+		r.Rounds = append(r.Rounds, round)
+	}
+	return r
+}
+
+func names(cs []meekstv.Candidate) string {
+	out := make([]string, len(cs))
+	for i, c := range cs {
+		out[i] = c.Name
+	}
+	return join(out)
+}
+
+// join lists items the way OpaVote does: "a", "a and b", "a, b and c"
+func join(items []string) string {
+	if len(items) <= 1 {
+		return strings.Join(items, "")
+	}
+	return strings.Join(items[:len(items)-1], ", ") + " and " + items[len(items)-1]
+}
+
+func keys(m map[int]bool) []int {
+	out := make([]int, 0, len(m))
+	for k := range m {
+		out = append(out, k)
+	}
+	sort.Ints(out)
+	return out
+}
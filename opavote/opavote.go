@@ -0,0 +1,97 @@
+// Package opavote reads and writes the JSON results format of OpaVote counts.
+package opavote
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+)
+
+// Report is the JSON results file that OpaVote publishes for a count.
+// Vote amounts are integers, scaled by 10^Precision.
+type Report struct {
+	NSeats      int             `json:"n_seats"`
+	NVotes      int             `json:"n_votes"`
+	Title       string          `json:"title"`
+	Precision   int             `json:"precision"`
+	Withdrawn   []int           `json:"withdrawn"`
+	Method      string          `json:"method"`
+	Version     string          `json:"version"`
+	Candidates  []string        `json:"candidates"`
+	Winners     []int           `json:"winners"`
+	TieBreaks   []interface{}   `json:"tie_breaks"`
+	Options     [][]interface{} `json:"options"`
+	Rounds      []Round         `json:"rounds"`
+	NValidVotes int             `json:"n_valid_votes"`
+}
+
+type Round struct {
+	Count      []int  `json:"count"`
+	Surplus    int    `json:"surplus"`
+	Exhausted  int    `json:"exhausted"`
+	Continuing []int  `json:"continuing"`
+	N          int    `json:"n"`
+	Msg        string `json:"msg"`
+	Winners    []int  `json:"winners"`
+	Losers     []int  `json:"losers"`
+	Thresh     int64  `json:"thresh"`
+	Action     Action `json:"action,omitempty"`
+}
+
+// Action is the event whose effects are counted in a round:
+// "first" for the count of first choices, "eliminate" after a defeat,
+// "surplus" after transferring the surplus of the elected candidates.
+type Action struct {
+	Type       string `json:"type"`
+	Candidates []int  `json:"candidates,omitempty"`
+	Desc       string `json:"desc"`
+}
+
+// Load reads a report in the OpaVote JSON results format.
+func Load(r io.Reader) (*Report, error) {
+	report := &Report{}
+	if err := json.NewDecoder(r).Decode(report); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// LoadFile reads the OpaVote JSON results file at path.
+func LoadFile(path string) (*Report, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Load(f)
+}
+
+// Write writes r in the OpaVote JSON results format.
+func (r *Report) Write(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+// Option returns the value of a count option, e.g. "prec" or "thresholdFormula".
+func (r *Report) Option(name string) (interface{}, bool) {
+	for _, opt := range r.Options {
+		if len(opt) == 2 && opt[0] == name {
+			return opt[1], true
+		}
+	}
+	return nil, false
+}
+
+// Scale converts a vote amount of the report to a number of votes.
+func (r *Report) Scale(amount int64) float64 {
+	return float64(amount) / pow10(r.Precision)
+}
+
+func pow10(n int) float64 {
+	p := 1.0
+	for i := 0; i < n; i++ {
+		p *= 10
+	}
+	return p
+}
@@ -0,0 +1,166 @@
+package opavote
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/linuxfoundation-it/meek-stv/election"
+	"github.com/linuxfoundation-it/meek-stv/meekstv"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func readBallots(t *testing.T, name string) *election.Election {
+	t.Helper()
+	f, err := os.Open("../testdata/" + name + ".txt")
+	require.NoError(t, err)
+	defer f.Close()
+	e, err := election.Parse(f)
+	require.NoError(t, err)
+	e.Unquote()
+	return e
+}
+
+func TestLoadFile(t *testing.T) {
+	r, err := LoadFile("../testdata/election13.json")
+	require.NoError(t, err)
+
+	assert.Equal(t, 2, r.NSeats)
+	assert.Equal(t, 6, r.Precision)
+	assert.Equal(t, []int{2}, r.Withdrawn)
+	assert.Equal(t, []int{0, 4}, r.Winners)
+	assert.Len(t, r.Rounds, 3)
+	assert.Equal(t, "eliminate", r.Rounds[1].Action.Type)
+	assert.Equal(t, []int{3}, r.Rounds[1].Action.Candidates)
+	assert.InDelta(t, 9318.333334, r.Scale(r.Rounds[0].Thresh), 1e-9)
+
+	v, ok := r.Option("thresholdFormula")
+	assert.True(t, ok)
+	assert.Equal(t, "Droop", v)
+}
+
+// divergence is a known difference between a count and its OpaVote report, after its first rounds
+type divergence struct {
+	Rounds int
+	Reason string
+}
+
+// loadDivergences reads the known differences with OpaVote, by election
+func loadDivergences(t *testing.T) map[string]divergence {
+	t.Helper()
+	data, err := os.ReadFile("../testdata/divergences.json")
+	require.NoError(t, err)
+	var out map[string]divergence
+	require.NoError(t, json.Unmarshal(data, &out))
+	return out
+}
+
+func TestFromLog(t *testing.T) {
+	divergences := loadDivergences(t)
+	for _, name := range []string{"election11", "election13", "election14", "chinese2020"} {
+		t.Run(name, func(t *testing.T) {
+			e := readBallots(t, name)
+			want, err := LoadFile("../testdata/" + name + ".json")
+			require.NoError(t, err)
+
+			l := meekstv.Count(e)
+			got := FromLog(&l, e, want.Precision)
+
+			assert.Equal(t, want.NSeats, got.NSeats)
+			assert.Equal(t, want.NVotes, got.NVotes)
+			assert.Equal(t, want.NValidVotes, got.NValidVotes)
+			assert.Equal(t, want.Title, got.Title)
+			assert.Equal(t, want.Candidates, got.Candidates)
+			assert.Equal(t, want.Withdrawn, got.Withdrawn)
+			assert.ElementsMatch(t, want.Winners, got.Winners)
+
+			n := len(want.Rounds)
+			if d, ok := divergences[name]; ok {
+				n = d.Rounds
+			}
+			require.Len(t, got.Rounds, len(want.Rounds))
+			const tol = 10000 // 0.01 votes
+			for i := 0; i < n; i++ {
+				w, g := want.Rounds[i], got.Rounds[i]
+				assert.Equal(t, w.N, g.N)
+				assert.Equal(t, w.Action, g.Action, "round %d action", w.N)
+				assert.ElementsMatch(t, w.Winners, g.Winners, "round %d winners", w.N)
+				assert.ElementsMatch(t, w.Losers, g.Losers, "round %d losers", w.N)
+				assert.ElementsMatch(t, w.Continuing, g.Continuing, "round %d continuing", w.N)
+				assert.InDelta(t, w.Thresh, g.Thresh, tol, "round %d threshold", w.N)
+				assert.InDelta(t, w.Exhausted, g.Exhausted, tol, "round %d exhausted", w.N)
+				assert.InDelta(t, w.Surplus, g.Surplus, tol, "round %d surplus", w.N)
+				for c := range w.Count {
+					assert.InDelta(t, w.Count[c], g.Count[c], tol, "round %d count of %d", w.N, c)
+				}
+				if !strings.Contains(w.Msg, "Keep factors") {
+					assert.Equal(t, w.Msg, g.Msg, "round %d message", w.N)
+				}
+			}
+		})
+	}
+}
+
+func TestWrite(t *testing.T) {
+	want, err := LoadFile("../testdata/medsci2022.json")
+	require.NoError(t, err)
+
+	buf := &bytes.Buffer{}
+	require.NoError(t, want.Write(buf))
+
+	got, err := Load(buf)
+	require.NoError(t, err)
+	assert.Equal(t, want, got)
+}
+
+func TestVerify(t *testing.T) {
+	e := readBallots(t, "election13")
+	want, err := LoadFile("../testdata/election13.json")
+	require.NoError(t, err)
+
+	mismatches, err := Verify(want, e, DefaultTolerance)
+	require.NoError(t, err)
+	assert.Empty(t, mismatches)
+
+	want.Rounds[1].Count[0] += 1000000
+	want.Rounds[2].Losers = []int{3}
+	mismatches, err = Verify(want, e, DefaultTolerance)
+	require.NoError(t, err)
+	assert.Equal(t, []Mismatch{
+		{Round: 2, Field: "votes", Candidate: "Zoe", Want: "7799", Got: "7798"},
+		{Round: 3, Field: "losers", Want: "[3]", Got: "[1 3]"},
+	}, mismatches)
+
+	mismatches, err = Verify(want, e, Tolerance{Votes: 2, Threshold: 0.01, Exhausted: 0.01})
+	require.NoError(t, err)
+	assert.Len(t, mismatches, 1)
+}
+
+func TestVerify_Unsupported(t *testing.T) {
+	e := readBallots(t, "election10")
+	want, err := LoadFile("../testdata/election10.json")
+	require.NoError(t, err)
+
+	_, err = Verify(want, e, DefaultTolerance)
+	assert.Error(t, err)
+}
+
+func TestCleanRules(t *testing.T) {
+	want, err := LoadFile("../testdata/election13.json")
+	require.NoError(t, err)
+
+	rules, err := want.CleanRules()
+	require.NoError(t, err)
+	assert.Equal(t, election.Rules{Overvotes: election.Skip, SkippedRanks: election.Skip}, rules)
+
+	for _, opt := range want.Options {
+		if opt[0] == "removeOvervotes" {
+			opt[1] = "Sometimes"
+		}
+	}
+	_, err = want.CleanRules()
+	assert.Error(t, err)
+}
@@ -0,0 +1,180 @@
+package opavote
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+
+	"github.com/linuxfoundation-it/meek-stv/election"
+	"github.com/linuxfoundation-it/meek-stv/meekstv"
+)
+
+// Tolerance is the largest difference, in votes, accepted between two counts.
+type Tolerance struct {
+	Votes     float64
+	Threshold float64
+	Exhausted float64
+}
+
+var DefaultTolerance = Tolerance{Votes: 0.01, Threshold: 0.01, Exhausted: 0.01}
+
+// Mismatch is a difference between two reports. Round is 0 for differences
+// that concern the whole count.
+type Mismatch struct {
+	Round     int    `json:"round"`
+	Field     string `json:"field"`
+	Candidate string `json:"candidate,omitempty"`
+	Want      string `json:"want"`
+	Got       string `json:"got"`
+}
+
+func (m Mismatch) String() string {
+	field := m.Field
+	if m.Candidate != "" {
+		field += fmt.Sprintf(" of %q", m.Candidate)
+	}
+	if m.Round == 0 {
+		return fmt.Sprintf("%s: want %s, got %s", field, m.Want, m.Got)
+	}
+	return fmt.Sprintf("round %d: %s: want %s, got %s", m.Round, field, m.Want, m.Got)
+}
+
+// Verify recounts e with the seats, withdrawn candidates and ballot cleaning rules of the OpaVote report want,
+// and compares the count with it. It fails if want was counted with options that
+// this implementation doesn't support, or if the count stops early, see meekstv.WithContext.
+func Verify(want *Report, e *election.Election, tol Tolerance, opts ...meekstv.Option) ([]Mismatch, error) {
+	if err := Supported(want); err != nil {
+		return nil, err
+	}
+	rules, err := want.CleanRules()
+	if err != nil {
+		return nil, err
+	}
+
+	recount := *e
+	rules.Apply(&recount)
+	recount.Seats = want.NSeats
+	recount.Withdrawn = make(map[int]bool)
+	for _, c := range want.Withdrawn {
+		if c < 0 || c >= e.Candidates {
+			return nil, fmt.Errorf("withdrawn candidate %d out of range", c)
+		}
+		recount.Withdrawn[c] = true
+	}
+
+	l := meekstv.Count(&recount, opts...)
+	if err := l.Err(); err != nil {
+		return nil, err
+	}
+	return Compare(want, FromLog(&l, &recount, want.Precision), tol), nil
+}
+
+// Supported returns an error if r was counted with options that this implementation doesn't support.
+func Supported(r *Report) error {
+	supported := map[string]interface{}{
+		"thresholdFormula":    "Droop",
+		"dynamicThreshold":    "Dynamic",
+		"fractionalThreshold": "Fractional",
+	}
+	for name, want := range supported {
+		if v, ok := r.Option(name); ok && v != want {
+			return fmt.Errorf("unsupported option %s: %v", name, v)
+		}
+	}
+	return nil
+}
+
+// CleanRules returns the ballot cleaning rules of the options removeOvervotes and removeUndervotes,
+// such as "Skip", the latter applying to skipped ranks. Missing options keep the ballots as they are.
+func (r *Report) CleanRules() (election.Rules, error) {
+	var rules election.Rules
+	for _, opt := range []struct {
+		name   string
+		policy *election.Policy
+	}{{"removeOvervotes", &rules.Overvotes}, {"removeUndervotes", &rules.SkippedRanks}} {
+		v, ok := r.Option(opt.name)
+		if !ok {
+			continue
+		}
+		s, _ := v.(string)
+		p, err := election.ParsePolicy(s)
+		if err != nil {
+			return rules, fmt.Errorf("unsupported option %s: %v", opt.name, v)
+		}
+		*opt.policy = p
+	}
+	return rules, nil
+}
+
+// Compare returns the differences between the rounds of two reports: candidate
+// counts, threshold, exhausted votes, winners, losers, action and tie-breaks.
+func Compare(want, got *Report, tol Tolerance) []Mismatch {
+	var out []Mismatch
+	add := func(round int, field, candidate string, want, got interface{}) {
+		out = append(out, Mismatch{Round: round, Field: field, Candidate: candidate, Want: fmt.Sprint(want), Got: fmt.Sprint(got)})
+	}
+
+	if len(want.Rounds) != len(got.Rounds) {
+		add(0, "rounds", "", len(want.Rounds), len(got.Rounds))
+	}
+	if !sameSet(want.Winners, got.Winners) {
+		add(0, "winners", "", sorted(want.Winners), sorted(got.Winners))
+	}
+	if len(want.TieBreaks) > 0 || len(got.TieBreaks) > 0 {
+		if !reflect.DeepEqual(want.TieBreaks, got.TieBreaks) {
+			add(0, "tie-breaks", "", want.TieBreaks, got.TieBreaks)
+		}
+	}
+
+	for i := 0; i < len(want.Rounds) && i < len(got.Rounds); i++ {
+		w, g := want.Rounds[i], got.Rounds[i]
+		n := w.N
+
+		if d := want.Scale(w.Thresh) - got.Scale(g.Thresh); math.Abs(d) > tol.Threshold {
+			add(n, "threshold", "", want.Scale(w.Thresh), got.Scale(g.Thresh))
+		}
+		for c := 0; c < len(w.Count) || c < len(g.Count); c++ {
+			var wv, gv float64
+			if c < len(w.Count) {
+				wv = want.Scale(int64(w.Count[c]))
+			}
+			if c < len(g.Count) {
+				gv = got.Scale(int64(g.Count[c]))
+			}
+			if math.Abs(wv-gv) > tol.Votes {
+				add(n, "votes", candidateName(want, c), wv, gv)
+			}
+		}
+		if d := want.Scale(int64(w.Exhausted)) - got.Scale(int64(g.Exhausted)); math.Abs(d) > tol.Exhausted {
+			add(n, "exhausted", "", want.Scale(int64(w.Exhausted)), got.Scale(int64(g.Exhausted)))
+		}
+		if !sameSet(w.Winners, g.Winners) {
+			add(n, "winners", "", sorted(w.Winners), sorted(g.Winners))
+		}
+		if !sameSet(w.Losers, g.Losers) {
+			add(n, "losers", "", sorted(w.Losers), sorted(g.Losers))
+		}
+		if w.Action.Type != g.Action.Type || !sameSet(w.Action.Candidates, g.Action.Candidates) {
+			add(n, "action", "", fmt.Sprint(w.Action.Type, sorted(w.Action.Candidates)), fmt.Sprint(g.Action.Type, sorted(g.Action.Candidates)))
+		}
+	}
+	return out
+}
+
+func candidateName(r *Report, c int) string {
+	if c < len(r.Candidates) {
+		return r.Candidates[c]
+	}
+	return fmt.Sprintf("candidate#%d", c)
+}
+
+func sameSet(a, b []int) bool {
+	return reflect.DeepEqual(sorted(a), sorted(b))
+}
+
+func sorted(ints []int) []int {
+	out := append([]int{}, ints...)
+	sort.Ints(out)
+	return out
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/linuxfoundation-it/meek-stv/meekstv"
+)
+
+func provenanceCmd(args []string, env *env) int {
+	fs := newFlagSet("provenance", "[ballot file]", "Counts an election and writes as CSV how the weight of every ballot line is split among the candidates in the final round.", env)
+	cf := addCountFlags(fs)
+	candidate := fs.String("candidate", "", "only write the ballot lines backing this candidate, by number (1-based) or name")
+
+	positional, err := parseFlags(fs, args)
+	if err != nil {
+		return usageError(err)
+	}
+	if len(positional) > 1 || *cf.seats < 0 {
+		fs.Usage()
+		return exitUsage
+	}
+
+	e, code := cf.load(first(positional), env)
+	if e == nil {
+		return code
+	}
+	candidates, err := parseCandidates(*candidate, e)
+	if err != nil || len(candidates) > 1 {
+		fmt.Fprintf(env.stderr, "invalid candidate %q\n", *candidate)
+		return exitUsage
+	}
+
+	report := meekstv.Count(e, meekstv.WithProvenance())
+	if len(candidates) == 1 {
+		err = report.Provenance().WriteSupportCSV(env.stdout, candidates[0])
+	} else {
+		err = report.Provenance().WriteCSV(env.stdout)
+	}
+	if err != nil {
+		fmt.Fprintln(env.stderr, err)
+		return exitFailure
+	}
+	return exitOK
+}
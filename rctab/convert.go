@@ -0,0 +1,122 @@
+package rctab
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/linuxfoundation-it/meek-stv/election"
+	"github.com/linuxfoundation-it/meek-stv/meekstv"
+)
+
+// DefaultPrecision is the number of decimals RCTab counts with by default.
+const DefaultPrecision = 4
+
+// exhausted is the transfer destination of exhausted votes
+const exhausted = "exhausted"
+
+// FromLog converts the log of a count of e into an RCTab summary, with vote amounts
+// rounded to precision decimals. The contest of the configuration is the title of e.
+//
+// The tally of a round lists the candidates elected or continuing when it starts. The candidates elected
+// or defeated in a round are its tally results, with the votes that flow from them in the next round:
+// the surplus of elected candidates, and all the votes of defeated ones. The winners left when the
+// seats are filled at the end of the count are elected in the last round.
+func FromLog(l *meekstv.Log, e *election.Election, precision int) *Summary {
+	format := func(v float64) string {
+		return formatVotes(v, precision)
+	}
+
+	s := &Summary{
+		Config:            Config{Contest: e.Title, GeneratedBy: "meek-stv"},
+		JSONFormatVersion: "1",
+		Results:           make([]Round, 0, l.NumRounds()),
+	}
+	reported, defeated := make(map[int]bool), make(map[int]bool)
+	for i := 0; i < l.NumRounds(); i++ {
+		entry := l.Round(i)
+		round := Round{
+			InactiveBallots: InactiveBallots{ExhaustedChoices: format(entry.Exhausted)},
+			Round:           i + 1,
+			Tally:           make(map[string]string),
+			TallyResults:    []TallyResult{},
+			Threshold:       format(entry.Threshold),
+		}
+		for _, c := range entry.CandidateSnapshot {
+			if !e.Withdrawn[c.Index] && !defeated[c.Index] {
+				round.Tally[c.Name] = format(c.Votes)
+			}
+		}
+
+		var next *meekstv.TransferMatrix
+		if i+1 < l.NumRounds() {
+			next = l.Round(i + 1).Transfers
+		}
+		elected := entry.Elected
+		if next == nil {
+			elected = nil
+			for _, c := range l.Results() {
+				if c.State == meekstv.Elected && !reported[c.Index] {
+					elected = append(elected, c)
+				}
+			}
+		}
+		for _, c := range elected {
+			reported[c.Index] = true
+			round.TallyResults = append(round.TallyResults, TallyResult{Elected: c.Name, Transfers: transfers(next, c.Index, entry, format)})
+		}
+		for _, c := range entry.Defeated {
+			defeated[c.Index] = true
+			round.TallyResults = append(round.TallyResults, TallyResult{Eliminated: c.Name, Transfers: transfers(next, c.Index, entry, format)})
+		}
+		s.Results = append(s.Results, round)
+	}
+
+	if n := l.NumRounds(); n > 0 {
+		last := l.Round(n - 1)
+		s.Summary = Totals{
+			FinalThreshold:      format(last.Threshold),
+			InactiveBallotCount: format(last.Exhausted),
+			NumWinners:          e.Seats,
+			TotalNumBallots:     e.TotalWeight().String(),
+			Undervotes:          e.CountEmpty().String(),
+		}
+	}
+	for c := 0; c < e.Candidates; c++ {
+		if !e.Withdrawn[c] {
+			s.Summary.NumCandidates++
+		}
+	}
+	return s
+}
+
+// transfers returns the votes that candidate c gives to each candidate, and to exhausted, in m.
+// Amounts that round to 0 are left out.
+func transfers(m *meekstv.TransferMatrix, c int, entry *meekstv.LogEntry, format func(float64) string) map[string]string {
+	out := make(map[string]string)
+	if m == nil {
+		return out
+	}
+	for to := 0; to <= m.Exhausted(); to++ {
+		v := m.At(c, to)
+		name := exhausted
+		if to != m.Exhausted() {
+			name = entry.CandidateSnapshot[to].Name
+		}
+		if s := format(v); s != "0" && to != c {
+			out[name] = s
+		}
+	}
+	return out
+}
+
+// formatVotes rounds v to precision decimals, without trailing zeros
+func formatVotes(v float64, precision int) string {
+	s := strconv.FormatFloat(v, 'f', precision, 64)
+	if strings.Contains(s, ".") {
+		s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+	}
+	if s == "-0" {
+		return "0"
+	}
+	return s
+}
@@ -0,0 +1,173 @@
+package rctab
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/linuxfoundation-it/meek-stv/election"
+)
+
+// UndeclaredWriteIns is the name RCTab gives to the candidate of undeclared write-ins.
+const UndeclaredWriteIns = "Undeclared Write-ins"
+
+// CSVOptions are the settings of a CSV cast vote record source in an RCTab contest configuration.
+// Indices are 1-based, as in RCTab.
+type CSVOptions struct {
+	Title string
+	// Seats defaults to 1
+	Seats int
+	// FirstVoteColumnIndex is the column of the first rank, the next columns holding the next ranks.
+	// It defaults to 1.
+	FirstVoteColumnIndex int
+	// FirstVoteRowIndex is the row of the first ballot. It defaults to 2, after a header row.
+	FirstVoteRowIndex int
+	// IDColumnIndex is the column of the IDs of the ballots, if any
+	IDColumnIndex int
+	// Candidates are the names of the candidates. When empty, the candidates are
+	// the names marked on the ballots, in order of appearance.
+	Candidates []string
+	// OvervoteDelimiter separates the candidates of a cell marking several, such as "|"
+	OvervoteDelimiter string
+	// OvervoteLabel marks a rank overvoted with unnamed candidates, UndervoteLabel a blank rank,
+	// and UndeclaredWriteInLabel a write-in, counted for the candidate UndeclaredWriteIns.
+	// Empty cells are blank ranks.
+	OvervoteLabel, UndervoteLabel, UndeclaredWriteInLabel string
+	// Rules clean the ballots as marked: overvotes, duplicate rankings and skipped ranks
+	Rules election.Rules
+}
+
+// ReadCSV reads cast vote records in the CSV layout of RCTab, a row per ballot of weight 1 and
+// a column per rank, each cell naming the candidate marked at its rank. It returns them as an
+// election along with what cleaning them changed.
+//
+// A rank marked with the overvote label can't be counted as equal rankings,
+// and is skipped when the overvote policy keeps overvotes.
+func ReadCSV(r io.Reader, opts CSVOptions) (*election.Election, election.CleanReport, error) {
+	var clean election.CleanReport
+	if opts.Seats == 0 {
+		opts.Seats = 1
+	}
+	if opts.FirstVoteColumnIndex == 0 {
+		opts.FirstVoteColumnIndex = 1
+	}
+	if opts.FirstVoteRowIndex == 0 {
+		opts.FirstVoteRowIndex = 2
+	}
+	if opts.Seats < 0 || opts.FirstVoteColumnIndex < 0 || opts.FirstVoteRowIndex < 0 || opts.IDColumnIndex < 0 {
+		return nil, clean, fmt.Errorf("negative seats or index")
+	}
+
+	e := &election.Election{Title: opts.Title, Seats: opts.Seats}
+	index := make(map[string]int)
+	add := func(name string) int {
+		index[name] = e.Candidates
+		e.Candidates++
+		e.CandidateNames = append(e.CandidateNames, name)
+		return index[name]
+	}
+	for _, name := range opts.Candidates {
+		if _, ok := index[name]; ok {
+			return nil, clean, fmt.Errorf("candidate %q is listed twice", name)
+		}
+		add(name)
+	}
+	candidate := func(name string) (int, error) {
+		if c, ok := index[name]; ok {
+			return c, nil
+		}
+		if len(opts.Candidates) > 0 && name != UndeclaredWriteIns {
+			return 0, fmt.Errorf("unknown candidate %q", name)
+		}
+		return add(name), nil
+	}
+
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.ReuseRecord = true
+	for row := 1; ; row++ {
+		record, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, clean, err
+		}
+		if row < opts.FirstVoteRowIndex {
+			continue
+		}
+
+		m := election.MarkedBallot{Weight: election.NewWeight(1)}
+		if opts.IDColumnIndex > 0 && opts.IDColumnIndex <= len(record) {
+			m.ID = strings.TrimSpace(record[opts.IDColumnIndex-1])
+		}
+		var overvotes []int
+		for col := opts.FirstVoteColumnIndex - 1; col >= 0 && col < len(record); col++ {
+			cell := strings.TrimSpace(record[col])
+			var rank []int
+			switch {
+			case cell == "" || cell == opts.UndervoteLabel:
+			case cell == opts.OvervoteLabel:
+				overvotes = append(overvotes, len(m.Ranks))
+			default:
+				names := []string{cell}
+				if opts.OvervoteDelimiter != "" {
+					names = strings.Split(cell, opts.OvervoteDelimiter)
+				}
+				for _, name := range names {
+					if name = strings.TrimSpace(name); name == opts.UndeclaredWriteInLabel {
+						name = UndeclaredWriteIns
+					}
+					c, err := candidate(name)
+					if err != nil {
+						return nil, clean, fmt.Errorf("row %d: %w", row, err)
+					}
+					rank = append(rank, c)
+				}
+			}
+			m.Ranks = append(m.Ranks, rank)
+		}
+
+		var report election.CleanReport
+		if b, ok := cleanRow(m, overvotes, opts.Rules, &report); ok {
+			e.Ballots = append(e.Ballots, b)
+		}
+		clean.Overvotes = clean.Overvotes.Add(report.Overvotes)
+		clean.Duplicates = clean.Duplicates.Add(report.Duplicates)
+		clean.SkippedRanks = clean.SkippedRanks.Add(report.SkippedRanks)
+		clean.Rejected = clean.Rejected.Add(report.Rejected)
+	}
+	return e, clean, nil
+}
+
+// cleanRow cleans m, whose ranks at overvotes are marked with the overvote label, into report:
+// the overvote policy applies to the first of them, and the others are skipped
+func cleanRow(m election.MarkedBallot, overvotes []int, rules election.Rules, report *election.CleanReport) (election.Ballot, bool) {
+	if len(overvotes) == 0 {
+		return rules.Clean(m, report)
+	}
+
+	switch rules.Overvotes {
+	case election.Reject:
+		report.Overvotes, report.Rejected = m.Weight, m.Weight
+		return election.Ballot{}, false
+	case election.Exhaust:
+		m.Ranks = m.Ranks[:overvotes[0]]
+	default:
+		ranks := make([][]int, 0, len(m.Ranks))
+		for k, rank := range m.Ranks {
+			if len(overvotes) > 0 && k == overvotes[0] {
+				overvotes = overvotes[1:]
+				continue
+			}
+			ranks = append(ranks, rank)
+		}
+		m.Ranks = ranks
+	}
+	b, ok := rules.Clean(m, report)
+	// the ballot is counted once, whether or not cleaning found other overvotes
+	report.Overvotes = m.Weight
+	return b, ok
+}
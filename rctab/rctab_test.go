@@ -0,0 +1,191 @@
+package rctab
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/linuxfoundation-it/meek-stv/election"
+	"github.com/linuxfoundation-it/meek-stv/meekstv"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var csvOptions = CSVOptions{
+	Title:                  "Mayor",
+	FirstVoteColumnIndex:   3,
+	IDColumnIndex:          1,
+	OvervoteDelimiter:      "|",
+	OvervoteLabel:          "overvote",
+	UndervoteLabel:         "undervote",
+	UndeclaredWriteInLabel: "UWI",
+}
+
+func readCSV(t *testing.T, opts CSVOptions) (*election.Election, election.CleanReport) {
+	t.Helper()
+	f, err := os.Open("../testdata/rctab/cvr.csv")
+	require.NoError(t, err)
+	defer f.Close()
+	e, report, err := ReadCSV(f, opts)
+	require.NoError(t, err)
+	return e, report
+}
+
+func TestReadCSV(t *testing.T) {
+	e, report := readCSV(t, csvOptions)
+	assert.Equal(t, "Mayor", e.Title)
+	assert.Equal(t, 1, e.Seats)
+	assert.Equal(t, []string{"Alice", "Bob", "Carol", "Dave", UndeclaredWriteIns}, e.CandidateNames)
+	// the overvote label is skipped, even when overvotes are kept
+	assert.Equal(t, election.CleanReport{Overvotes: election.NewWeight(1)}, report)
+
+	require.Len(t, e.Ballots, 10)
+	got := make(map[string][][]int)
+	for _, b := range e.Ballots {
+		assert.Equal(t, election.NewWeight(1), b.Weight)
+		got[b.ID] = b.Groups()
+	}
+	assert.Equal(t, [][]int{{0}, {1}, {2}}, got["b-001"])
+	assert.Equal(t, [][]int{{2}, {1}}, got["b-003"])
+	assert.Equal(t, [][]int{{0}, {3}}, got["b-004"])
+	assert.Equal(t, [][]int{{3}, {1, 2}, {0}}, got["b-005"])
+	assert.Equal(t, [][]int{{0}, {0}, {3}}, got["b-006"])
+	assert.Equal(t, [][]int{{4}, {2}}, got["b-007"])
+	assert.Empty(t, got["b-008"])
+	assert.Equal(t, [][]int{{2}, {3}, {1}, {0}}, got["b-010"])
+}
+
+func TestReadCSV_Rules(t *testing.T) {
+	opts := csvOptions
+	opts.Rules = election.Rules{Overvotes: election.Exhaust, Duplicates: election.Skip, SkippedRanks: election.Reject}
+	e, report := readCSV(t, opts)
+	one := election.NewWeight(1)
+	assert.Equal(t, election.CleanReport{Overvotes: election.NewWeight(2), Duplicates: one, SkippedRanks: one, Rejected: one}, report)
+
+	got := make(map[string][]int)
+	for _, b := range e.Ballots {
+		got[b.ID] = b.Preferences
+	}
+	assert.NotContains(t, got, "b-003")
+	assert.Equal(t, []int{0}, got["b-004"])
+	assert.Equal(t, []int{3}, got["b-005"])
+	assert.Equal(t, []int{0, 3}, got["b-006"])
+
+	opts.Rules = election.Rules{Overvotes: election.Reject}
+	e, report = readCSV(t, opts)
+	assert.Len(t, e.Ballots, 8)
+	assert.Equal(t, election.NewWeight(2), report.Rejected)
+}
+
+func TestReadCSV_Candidates(t *testing.T) {
+	opts := csvOptions
+	opts.Candidates = []string{"Dave", "Carol", "Bob", "Alice"}
+	e, _ := readCSV(t, opts)
+	assert.Equal(t, []string{"Dave", "Carol", "Bob", "Alice", UndeclaredWriteIns}, e.CandidateNames)
+	assert.Equal(t, []int{3, 2, 1}, e.Ballots[0].Preferences)
+
+	opts.Candidates = []string{"Alice", "Bob"}
+	f, err := os.Open("../testdata/rctab/cvr.csv")
+	require.NoError(t, err)
+	defer f.Close()
+	_, _, err = ReadCSV(f, opts)
+	assert.ErrorContains(t, err, `row 2: unknown candidate "Carol"`)
+
+	_, _, err = ReadCSV(strings.NewReader("a\n\"b\n"), CSVOptions{})
+	assert.Error(t, err)
+}
+
+func readBallots(t *testing.T, path string) *election.Election {
+	t.Helper()
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+	e, err := election.Parse(f)
+	require.NoError(t, err)
+	e.Unquote()
+	return e
+}
+
+func TestFromLog(t *testing.T) {
+	files, err := filepath.Glob("../testdata/*.txt")
+	require.NoError(t, err)
+	for _, path := range files {
+		t.Run(strings.TrimSuffix(filepath.Base(path), ".txt"), func(t *testing.T) {
+			e := readBallots(t, path)
+			l := meekstv.Count(e)
+			s := FromLog(&l, e, DefaultPrecision)
+
+			require.Len(t, s.Results, l.NumRounds())
+			assert.Equal(t, e.Seats, s.Summary.NumWinners)
+			assert.Equal(t, e.TotalWeight().String(), s.Summary.TotalNumBallots)
+
+			elected := 0
+			for i, round := range s.Results {
+				assert.Equal(t, i+1, round.Round)
+				entry := l.Round(i)
+				for _, c := range entry.CandidateSnapshot {
+					if v, ok := round.Tally[c.Name]; ok {
+						assert.Equal(t, formatVotes(c.Votes, DefaultPrecision), v)
+					} else {
+						assert.Contains(t, []meekstv.CandidateState{meekstv.Defeated, meekstv.Withdrawn}, c.State, "round %d: %s", i+1, c.Name)
+					}
+				}
+				if i+1 < len(s.Results) {
+					assert.Len(t, round.TallyResults, len(entry.Elected)+len(entry.Defeated))
+				}
+				for _, r := range round.TallyResults {
+					if r.Elected != "" {
+						elected++
+					}
+				}
+			}
+			assert.Equal(t, len(l.Winners()), elected)
+		})
+	}
+}
+
+func TestFromLog_Transfers(t *testing.T) {
+	e := &election.Election{
+		Title:          "Transfers",
+		Candidates:     3,
+		Seats:          1,
+		CandidateNames: []string{"A", "B", "C"},
+		Ballots: []election.Ballot{
+			{Weight: election.NewWeight(5), Preferences: []int{0}},
+			{Weight: election.NewWeight(2), Preferences: []int{1, 2}},
+			{Weight: election.NewWeight(1), Preferences: []int{1}},
+			{Weight: election.NewWeight(4), Preferences: []int{2}},
+		},
+	}
+	l := meekstv.Count(e)
+	s := FromLog(&l, e, DefaultPrecision)
+
+	require.Len(t, s.Results, 2)
+	first := s.Results[0]
+	assert.Equal(t, map[string]string{"A": "5", "B": "3", "C": "4"}, first.Tally)
+	assert.Equal(t, "6", first.Threshold)
+	assert.Equal(t, []TallyResult{{Eliminated: "B", Transfers: map[string]string{"C": "2", "exhausted": "1"}}}, first.TallyResults)
+
+	second := s.Results[1]
+	assert.Equal(t, map[string]string{"A": "5", "C": "6"}, second.Tally)
+	assert.Equal(t, "5.5", second.Threshold)
+	assert.Equal(t, "1", second.InactiveBallots.ExhaustedChoices)
+	assert.Equal(t, []TallyResult{{Elected: "C", Transfers: map[string]string{}}}, second.TallyResults)
+
+	buf := &bytes.Buffer{}
+	require.NoError(t, s.Write(buf))
+	assert.Contains(t, buf.String(), `"jsonFormatVersion": "1"`)
+	got, err := LoadSummary(buf)
+	require.NoError(t, err)
+	assert.Equal(t, s, got)
+}
+
+func TestFormatVotes(t *testing.T) {
+	assert.Equal(t, "12", formatVotes(12, 4))
+	assert.Equal(t, "12.5", formatVotes(12.5, 4))
+	assert.Equal(t, "0.3333", formatVotes(1.0/3, 4))
+	assert.Equal(t, "0", formatVotes(-1e-12, 4))
+	assert.Equal(t, "100", formatVotes(100, 0))
+}
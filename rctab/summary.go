@@ -0,0 +1,75 @@
+// Package rctab reads and writes the formats of RCTab, the Universal RCV Tabulator:
+// cast vote records in its CSV layout, and the summary JSON of its results.
+package rctab
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// Summary is the summary JSON file that RCTab writes for a count.
+// Vote amounts are decimal numbers written as strings.
+type Summary struct {
+	Config            Config  `json:"config"`
+	JSONFormatVersion string  `json:"jsonFormatVersion"`
+	Results           []Round `json:"results"`
+	Summary           Totals  `json:"summary"`
+}
+
+// Config describes the contest.
+type Config struct {
+	Contest      string `json:"contest"`
+	Date         string `json:"date"`
+	GeneratedBy  string `json:"generatedBy"`
+	Jurisdiction string `json:"jurisdiction"`
+	Office       string `json:"office"`
+}
+
+// Round holds the votes of the continuing and elected candidates, by name,
+// and the candidates elected or eliminated in the round, with the votes they transfer in the next.
+type Round struct {
+	InactiveBallots InactiveBallots   `json:"inactiveBallots"`
+	Round           int               `json:"round"`
+	Tally           map[string]string `json:"tally"`
+	TallyResults    []TallyResult     `json:"tallyResults"`
+	Threshold       string            `json:"threshold"`
+}
+
+// InactiveBallots is the weight of the ballots no longer counted for any candidate.
+type InactiveBallots struct {
+	ExhaustedChoices string `json:"exhaustedChoices"`
+}
+
+// TallyResult is a candidate elected or eliminated, and the votes it transfers to each
+// candidate, by name, or to "exhausted".
+type TallyResult struct {
+	Elected    string            `json:"elected,omitempty"`
+	Eliminated string            `json:"eliminated,omitempty"`
+	Transfers  map[string]string `json:"transfers"`
+}
+
+// Totals are the totals of the count.
+type Totals struct {
+	FinalThreshold      string `json:"finalThreshold"`
+	InactiveBallotCount string `json:"inactiveBallotCount"`
+	NumCandidates       int    `json:"numCandidates"`
+	NumWinners          int    `json:"numWinners"`
+	TotalNumBallots     string `json:"totalNumBallots"`
+	Undervotes          string `json:"undervotes"`
+}
+
+// LoadSummary reads a summary in the RCTab JSON format.
+func LoadSummary(r io.Reader) (*Summary, error) {
+	var s Summary
+	if err := json.NewDecoder(r).Decode(&s); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// Write writes the summary in the RCTab JSON format.
+func (s *Summary) Write(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(s)
+}
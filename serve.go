@@ -0,0 +1,37 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/linuxfoundation-it/meek-stv/server"
+)
+
+func serveCmd(args []string, env *env) int {
+	fs := newFlagSet("serve", "", "Serves the HTTP JSON API for counting, validating, seat sweeps and OpaVote verification.\nThe API is described at /openapi.json.", env)
+	addr := fs.String("addr", "localhost:8080", "address to listen on")
+	maxBody := fs.Int64("max-body", server.DefaultConfig.MaxBodyBytes, "largest request body accepted, in bytes")
+	timeout := fs.Duration("timeout", server.DefaultConfig.Timeout, "time limit of a request")
+
+	positional, err := parseFlags(fs, args)
+	if err != nil {
+		return usageError(err)
+	}
+	if len(positional) > 0 || *maxBody <= 0 || *timeout <= 0 {
+		fs.Usage()
+		return exitUsage
+	}
+
+	srv := &http.Server{
+		Addr:              *addr,
+		Handler:           server.New(server.Config{MaxBodyBytes: *maxBody, Timeout: *timeout}),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	fmt.Fprintf(env.stderr, "listening on %s\n", *addr)
+	if err := srv.ListenAndServe(); err != nil {
+		fmt.Fprintln(env.stderr, err)
+		return exitFailure
+	}
+	return exitOK
+}
@@ -0,0 +1,312 @@
+// Package server exposes counting, validation, seat sweeps and OpaVote verification as an HTTP JSON API.
+// The API is described by the OpenAPI document served at /openapi.json.
+package server
+
+import (
+	"bytes"
+	"context"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/linuxfoundation-it/meek-stv/election"
+	"github.com/linuxfoundation-it/meek-stv/meekstv"
+	"github.com/linuxfoundation-it/meek-stv/opavote"
+)
+
+//go:embed openapi.json
+var openAPI []byte
+
+type Config struct {
+	// MaxBodyBytes is the largest request body accepted. Defaults to DefaultConfig.MaxBodyBytes.
+	MaxBodyBytes int64
+	// Timeout bounds the time spent on a request. Defaults to DefaultConfig.Timeout.
+	Timeout time.Duration
+}
+
+var DefaultConfig = Config{
+	MaxBodyBytes: 10 << 20,
+	Timeout:      30 * time.Second,
+}
+
+// New returns the handler of the API.
+func New(cfg Config) http.Handler {
+	if cfg.MaxBodyBytes <= 0 {
+		cfg.MaxBodyBytes = DefaultConfig.MaxBodyBytes
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = DefaultConfig.Timeout
+	}
+	s := &server{cfg: cfg}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /v1/count", s.handle(s.count))
+	mux.HandleFunc("POST /v1/validate", s.handle(s.validate))
+	mux.HandleFunc("POST /v1/sweep", s.handle(s.sweep))
+	mux.HandleFunc("POST /v1/verify", s.handle(s.verify))
+	mux.HandleFunc("GET /openapi.json", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(openAPI)
+	})
+	return mux
+}
+
+type server struct {
+	cfg Config
+}
+
+// Request holds the ballots of an election, either as an OpaVote ballot file in Ballots,
+// or in the JSON ballot format in Election, and the options of the count.
+type Request struct {
+	Ballots  string          `json:"ballots,omitempty"`
+	Election json.RawMessage `json:"election,omitempty"`
+	// Seats overrides the number of seats of the ballots when positive
+	Seats int `json:"seats,omitempty"`
+	// Withdrawn lists the 0-based indices of candidates to withdraw, in addition to those of the ballots
+	Withdrawn []int `json:"withdrawn,omitempty"`
+
+	// SeatsFrom and SeatsTo are the range of seats counted by a sweep
+	SeatsFrom int `json:"seats_from,omitempty"`
+	SeatsTo   int `json:"seats_to,omitempty"`
+
+	// OpaVote is the OpaVote JSON results checked by verify
+	OpaVote   *opavote.Report `json:"opavote,omitempty"`
+	Tolerance *Tolerance      `json:"tolerance,omitempty"`
+}
+
+type Tolerance struct {
+	Votes     float64 `json:"votes"`
+	Threshold float64 `json:"threshold"`
+	Exhausted float64 `json:"exhausted"`
+}
+
+type CountResponse struct {
+	Title   string            `json:"title"`
+	Seats   int               `json:"seats"`
+	Winners []meekstv.Winner  `json:"winners"`
+	Log     *meekstv.Document `json:"log"`
+}
+
+type ValidateResponse struct {
+	Valid      bool     `json:"valid"`
+	Error      string   `json:"error,omitempty"`
+	Warnings   []string `json:"warnings"`
+	Candidates int      `json:"candidates"`
+	Seats      int      `json:"seats"`
+	Ballots    int      `json:"ballots"`
+}
+
+type SweepResponse struct {
+	Title   string        `json:"title"`
+	Results []SweepResult `json:"results"`
+}
+
+type SweepResult struct {
+	Seats   int              `json:"seats"`
+	Winners []meekstv.Winner `json:"winners"`
+}
+
+type VerifyResponse struct {
+	Match      bool               `json:"match"`
+	Mismatches []opavote.Mismatch `json:"mismatches"`
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// httpError is an error with the status code it is reported with
+type httpError struct {
+	status int
+	err    error
+}
+
+func (e *httpError) Error() string {
+	return e.err.Error()
+}
+
+func errorf(status int, format string, args ...any) error {
+	return &httpError{status: status, err: fmt.Errorf(format, args...)}
+}
+
+// handle decodes the request, runs f within the request timeout, and encodes its response
+func (s *server) handle(f func(context.Context, *Request) (any, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), s.cfg.Timeout)
+		defer cancel()
+
+		var req Request
+		dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, s.cfg.MaxBodyBytes))
+		if err := dec.Decode(&req); err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				writeError(w, errorf(http.StatusRequestEntityTooLarge, "request body larger than %d bytes", tooLarge.Limit))
+				return
+			}
+			writeError(w, errorf(http.StatusBadRequest, "invalid request: %v", err))
+			return
+		}
+
+		if ctx.Err() != nil {
+			writeError(w, errorf(http.StatusServiceUnavailable, "request timed out after %s", s.cfg.Timeout))
+			return
+		}
+
+		// counts stop at the next round once the context expires
+		resp, err := f(ctx, &req)
+		if errors.Is(err, context.DeadlineExceeded) {
+			writeError(w, errorf(http.StatusServiceUnavailable, "request timed out after %s", s.cfg.Timeout))
+			return
+		}
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, resp)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	var he *httpError
+	if errors.As(err, &he) {
+		status = he.status
+	}
+	writeJSON(w, status, errorResponse{Error: err.Error()})
+}
+
+// election parses the ballots of the request and applies its options
+func (req *Request) election() (*election.Election, error) {
+	var e *election.Election
+	var err error
+	switch {
+	case req.Ballots != "" && len(req.Election) > 0:
+		return nil, errorf(http.StatusBadRequest, "only one of ballots and election can be set")
+	case req.Ballots != "":
+		e, err = election.Parse(strings.NewReader(req.Ballots))
+	case len(req.Election) > 0:
+		e, err = election.ReadJSON(bytes.NewReader(req.Election))
+	default:
+		return nil, errorf(http.StatusBadRequest, "missing ballots or election")
+	}
+	if err != nil {
+		return nil, errorf(http.StatusUnprocessableEntity, "invalid ballots: %v", err)
+	}
+	e.Unquote()
+
+	if err := e.Override(req.Seats, req.Withdrawn); err != nil {
+		return nil, errorf(http.StatusBadRequest, "%v", err)
+	}
+	return e, nil
+}
+
+func (s *server) count(ctx context.Context, req *Request) (any, error) {
+	e, err := req.election()
+	if err != nil {
+		return nil, err
+	}
+	l := meekstv.Count(e, meekstv.WithContext(ctx))
+	if err := l.Err(); err != nil {
+		return nil, err
+	}
+	return &CountResponse{
+		Title:   e.Title,
+		Seats:   e.Seats,
+		Winners: l.Elected(),
+		Log:     l.Document(),
+	}, nil
+}
+
+func (s *server) validate(ctx context.Context, req *Request) (any, error) {
+	e, err := req.election()
+	if err != nil {
+		var he *httpError
+		if errors.As(err, &he) && he.status == http.StatusUnprocessableEntity {
+			return &ValidateResponse{Error: err.Error(), Warnings: []string{}}, nil
+		}
+		return nil, err
+	}
+	warnings := e.Warnings()
+	if warnings == nil {
+		warnings = []string{}
+	}
+	return &ValidateResponse{
+		Valid:      true,
+		Warnings:   warnings,
+		Candidates: e.Candidates,
+		Seats:      e.Seats,
+		Ballots:    len(e.Ballots),
+	}, nil
+}
+
+func (s *server) sweep(ctx context.Context, req *Request) (any, error) {
+	e, err := req.election()
+	if err != nil {
+		return nil, err
+	}
+	// the sweep starts with the seats reserved for classes, if any
+	from, to := req.SeatsFrom, req.SeatsTo
+	if from == 0 {
+		from = max(e.Seats-e.OpenSeats(), 1)
+	}
+	if to == 0 {
+		to = e.Candidates
+	}
+	if from < 1 || to < from || to > e.Candidates {
+		return nil, errorf(http.StatusBadRequest, "invalid seats range %d-%d for %d candidates", from, to, e.Candidates)
+	}
+
+	resp := &SweepResponse{Title: e.Title}
+	for seats := from; seats <= to; seats++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		recount := *e
+		recount.Seats = seats
+		if err := recount.ValidateClasses(); err != nil {
+			return nil, errorf(http.StatusBadRequest, "%d seats: %v", seats, err)
+		}
+		l := meekstv.Count(&recount, meekstv.WithContext(ctx))
+		if err := l.Err(); err != nil {
+			return nil, err
+		}
+		resp.Results = append(resp.Results, SweepResult{Seats: seats, Winners: l.Elected()})
+	}
+	return resp, nil
+}
+
+func (s *server) verify(ctx context.Context, req *Request) (any, error) {
+	if req.OpaVote == nil {
+		return nil, errorf(http.StatusBadRequest, "missing opavote results")
+	}
+	e, err := req.election()
+	if err != nil {
+		return nil, err
+	}
+	tol := opavote.DefaultTolerance
+	if req.Tolerance != nil {
+		tol = opavote.Tolerance(*req.Tolerance)
+	}
+
+	mismatches, err := opavote.Verify(req.OpaVote, e, tol, meekstv.WithContext(ctx))
+	if err != nil && ctx.Err() != nil {
+		return nil, err
+	}
+	if err != nil {
+		return nil, errorf(http.StatusUnprocessableEntity, "%v", err)
+	}
+	if mismatches == nil {
+		mismatches = []opavote.Mismatch{}
+	}
+	return &VerifyResponse{Match: len(mismatches) == 0, Mismatches: mismatches}, nil
+}
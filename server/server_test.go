@@ -0,0 +1,236 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/linuxfoundation-it/meek-stv/meekstv"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func ballots(t *testing.T, name string) string {
+	t.Helper()
+	b, err := os.ReadFile("../testdata/" + name)
+	require.NoError(t, err)
+	return string(b)
+}
+
+// post sends req to path and decodes the response into resp
+func post(t *testing.T, h http.Handler, path string, req any, resp any) int {
+	t.Helper()
+	body, err := json.Marshal(req)
+	require.NoError(t, err)
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, path, bytes.NewReader(body)))
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), resp))
+	return rec.Code
+}
+
+func TestCount(t *testing.T) {
+	h := New(DefaultConfig)
+
+	var resp CountResponse
+	code := post(t, h, "/v1/count", Request{Ballots: ballots(t, "election13.txt")}, &resp)
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "Stack Overflow Moderator Election 2021", resp.Title)
+	assert.ElementsMatch(t, []meekstv.Winner{{Index: 0, Name: "Zoe"}, {Index: 4, Name: "Stephen Rauch"}}, resp.Winners)
+	assert.Equal(t, meekstv.SchemaVersion, resp.Log.SchemaVersion)
+	assert.Len(t, resp.Log.Rounds, 3)
+
+	// the same election in the JSON format, with options
+	election := `{"title": "T", "seats": 1, "candidates": ["A", "B", "C"],
+		"ballots": [{"weight": 3, "preferences": [0, 1]}, {"weight": 2, "preferences": [1]}, {"weight": 2, "preferences": [2, 1]}]}`
+	resp = CountResponse{}
+	code = post(t, h, "/v1/count", Request{Election: json.RawMessage(election), Seats: 2, Withdrawn: []int{0}}, &resp)
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, 2, resp.Seats)
+	assert.ElementsMatch(t, []meekstv.Winner{{Index: 1, Name: "B"}, {Index: 2, Name: "C"}}, resp.Winners)
+}
+
+func TestCount_Errors(t *testing.T) {
+	h := New(DefaultConfig)
+
+	tests := []struct {
+		name string
+		req  Request
+		code int
+	}{
+		{"missing ballots", Request{}, http.StatusBadRequest},
+		{"both formats", Request{Ballots: "x", Election: json.RawMessage(`{}`)}, http.StatusBadRequest},
+		{"invalid ballots", Request{Ballots: "2 1\n1 3 0\n0\n"}, http.StatusUnprocessableEntity},
+		{"negative seats", Request{Ballots: ballots(t, "election13.txt"), Seats: -1}, http.StatusBadRequest},
+		{"withdrawn out of range", Request{Ballots: ballots(t, "election13.txt"), Withdrawn: []int{6}}, http.StatusBadRequest},
+		{"seats below classes", Request{Election: json.RawMessage(classElection), Seats: 1}, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var resp errorResponse
+			assert.Equal(t, tt.code, post(t, h, "/v1/count", tt.req, &resp))
+			assert.NotEmpty(t, resp.Error)
+		})
+	}
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/v1/count", strings.NewReader("{")))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/count", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+}
+
+// classElection reserves both of its seats for APAC
+const classElection = `{"title": "T", "seats": 2, "candidates": ["A", "B", "C"],
+	"classes": [{"name": "APAC", "seats": 2, "candidates": [1, 2]}],
+	"ballots": [{"weight": 5, "preferences": [0]}, {"weight": 4, "preferences": [1]}, {"weight": 3, "preferences": [2]}]}`
+
+func TestLimits(t *testing.T) {
+	var resp errorResponse
+	h := New(Config{MaxBodyBytes: 1024})
+	code := post(t, h, "/v1/count", Request{Ballots: ballots(t, "election13.txt")}, &resp)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, code)
+
+	h = New(Config{Timeout: time.Nanosecond})
+	code = post(t, h, "/v1/count", Request{Ballots: ballots(t, "election13.txt")}, &resp)
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Contains(t, resp.Error, "timed out")
+}
+
+// expiringContext expires once Err has been called a given number of times
+type expiringContext struct {
+	context.Context
+	checks int
+}
+
+func (c *expiringContext) Err() error {
+	if c.checks == 0 {
+		return context.DeadlineExceeded
+	}
+	c.checks--
+	return nil
+}
+
+func TestLimits_MidCount(t *testing.T) {
+	s := &server{cfg: DefaultConfig}
+	req := &Request{Ballots: ballots(t, "election13.txt")}
+
+	// the deadline expires after the first of the three rounds of the count
+	ctx := &expiringContext{Context: context.Background(), checks: 1}
+	_, err := s.count(ctx, req)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Zero(t, ctx.checks)
+
+	// and in the middle of a sweep, which stops with the count it is running
+	req.SeatsFrom, req.SeatsTo = 1, 3
+	ctx = &expiringContext{Context: context.Background(), checks: 6}
+	_, err = s.sweep(ctx, req)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestLimits_LateError(t *testing.T) {
+	// an invalid request is reported as such even when the deadline has expired meanwhile
+	s := &server{cfg: Config{MaxBodyBytes: 1024, Timeout: 10 * time.Millisecond}}
+	h := s.handle(func(ctx context.Context, req *Request) (any, error) {
+		<-ctx.Done()
+		return nil, errorf(http.StatusBadRequest, "invalid ballots")
+	})
+
+	var resp errorResponse
+	code := post(t, h, "/v1/count", Request{}, &resp)
+	assert.Equal(t, http.StatusBadRequest, code)
+	assert.Equal(t, "invalid ballots", resp.Error)
+}
+
+func TestValidate(t *testing.T) {
+	h := New(DefaultConfig)
+
+	var resp ValidateResponse
+	code := post(t, h, "/v1/validate", Request{Ballots: ballots(t, "election13.txt"), Seats: 9}, &resp)
+	require.Equal(t, http.StatusOK, code)
+	assert.True(t, resp.Valid)
+	assert.Equal(t, 6, resp.Candidates)
+	assert.Contains(t, resp.Warnings, "9 seats for 6 candidates")
+
+	resp = ValidateResponse{}
+	code = post(t, h, "/v1/validate", Request{Ballots: "2 1\n1 3 0\n0\n"}, &resp)
+	require.Equal(t, http.StatusOK, code)
+	assert.False(t, resp.Valid)
+	assert.Contains(t, resp.Error, "candidate 3 out of range")
+}
+
+func TestSweep(t *testing.T) {
+	h := New(DefaultConfig)
+
+	var resp SweepResponse
+	code := post(t, h, "/v1/sweep", Request{Ballots: ballots(t, "election13.txt"), SeatsFrom: 1, SeatsTo: 3}, &resp)
+	require.Equal(t, http.StatusOK, code)
+	require.Len(t, resp.Results, 3)
+	for i, r := range resp.Results {
+		assert.Equal(t, i+1, r.Seats)
+		assert.Len(t, r.Winners, i+1)
+	}
+
+	var errResp errorResponse
+	code = post(t, h, "/v1/sweep", Request{Ballots: ballots(t, "election13.txt"), SeatsFrom: 3, SeatsTo: 7}, &errResp)
+	assert.Equal(t, http.StatusBadRequest, code)
+
+	// the sweep starts with the reserved seats, and can't count fewer
+	resp = SweepResponse{}
+	code = post(t, h, "/v1/sweep", Request{Election: json.RawMessage(classElection)}, &resp)
+	require.Equal(t, http.StatusOK, code)
+	require.Len(t, resp.Results, 2)
+	assert.Equal(t, 2, resp.Results[0].Seats)
+
+	code = post(t, h, "/v1/sweep", Request{Election: json.RawMessage(classElection), SeatsFrom: 1}, &errResp)
+	assert.Equal(t, http.StatusBadRequest, code)
+}
+
+func TestVerify(t *testing.T) {
+	h := New(DefaultConfig)
+
+	var report json.RawMessage = []byte(ballots(t, "election13.json"))
+	body := map[string]any{"ballots": ballots(t, "election13.txt"), "opavote": report}
+
+	var resp VerifyResponse
+	code := post(t, h, "/v1/verify", body, &resp)
+	require.Equal(t, http.StatusOK, code)
+	assert.True(t, resp.Match, "%v", resp.Mismatches)
+	assert.Empty(t, resp.Mismatches)
+
+	// the same results don't match another election
+	resp = VerifyResponse{}
+	body["ballots"] = ballots(t, "election14.txt")
+	code = post(t, h, "/v1/verify", body, &resp)
+	require.Equal(t, http.StatusOK, code)
+	assert.False(t, resp.Match)
+
+	var errResp errorResponse
+	code = post(t, h, "/v1/verify", Request{Ballots: ballots(t, "election13.txt")}, &errResp)
+	assert.Equal(t, http.StatusBadRequest, code)
+}
+
+func TestOpenAPI(t *testing.T) {
+	rec := httptest.NewRecorder()
+	New(DefaultConfig).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var doc struct {
+		OpenAPI string                    `json:"openapi"`
+		Paths   map[string]map[string]any `json:"paths"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &doc))
+	assert.Equal(t, "3.0.3", doc.OpenAPI)
+	for _, path := range []string{"/v1/count", "/v1/validate", "/v1/sweep", "/v1/verify"} {
+		assert.Contains(t, doc.Paths[path], "post", path)
+	}
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/linuxfoundation-it/meek-stv/stats"
+)
+
+func statsCmd(args []string, env *env) int {
+	fs := newFlagSet("stats", "[ballot file]", "Shows first preferences, ranking lengths, rank positions, common ballots and candidates ranked together.", env)
+	withdraw := fs.String("withdraw", "", "comma separated candidates to withdraw, by number (1-based) or name")
+	patterns := fs.Int("patterns", stats.DefaultPatterns, "number of most common ballots to show")
+	format := fs.String("format", "text", "output format: text or json")
+	input := addInputFlags(fs)
+
+	positional, err := parseFlags(fs, args)
+	if err != nil {
+		return usageError(err)
+	}
+	if len(positional) > 1 || *patterns <= 0 {
+		fs.Usage()
+		return exitUsage
+	}
+
+	e, code, err := input.read(first(positional), env.stderr, env)
+	if err != nil {
+		fmt.Fprintln(env.stderr, err)
+		return code
+	}
+	withdrawn, err := parseCandidates(*withdraw, e)
+	if err != nil {
+		fmt.Fprintln(env.stderr, err)
+		return exitUsage
+	}
+	if err := e.Override(0, withdrawn); err != nil {
+		fmt.Fprintln(env.stderr, err)
+		return exitUsage
+	}
+
+	report := stats.Compute(e, stats.Options{Patterns: *patterns})
+	switch *format {
+	case "text":
+		err = report.WriteText(env.stdout)
+	case "json":
+		err = report.WriteJSON(env.stdout)
+	default:
+		fmt.Fprintf(env.stderr, "unknown output format %q\n", *format)
+		return exitUsage
+	}
+	if err != nil {
+		fmt.Fprintln(env.stderr, err)
+		return exitFailure
+	}
+	return exitOK
+}
@@ -0,0 +1,284 @@
+// Package stats computes descriptive statistics of the ballots of an election, before counting.
+package stats
+
+import (
+	"encoding/json"
+	"io"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/linuxfoundation-it/meek-stv/election"
+)
+
+// DefaultPatterns is the number of most common ballots reported by default.
+const DefaultPatterns = 10
+
+type Options struct {
+	// Patterns is the number of most common ballots to report. Defaults to DefaultPatterns.
+	Patterns int
+}
+
+// Report holds the statistics of an election. All figures count physical ballots, that is
+// ballot lines multiplied by their weight, which may be fractional. Blank ballots, and ballots that only rank withdrawn
+// candidates, are counted as empty as election.Election.CountEmpty does, and are left out of
+// every other figure.
+type Report struct {
+	Title        string  `json:"title"`
+	Seats        int     `json:"seats"`
+	Ballots      float64 `json:"ballots"`
+	Valid        float64 `json:"valid"`
+	Blank        float64 `json:"blank"`
+	AllWithdrawn float64 `json:"all_withdrawn"`
+
+	// AverageLength and MedianLength are the number of candidates ranked on valid ballots
+	AverageLength float64 `json:"average_length"`
+	MedianLength  float64 `json:"median_length"`
+	// Lengths[n] is the number of valid ballots ranking exactly n candidates
+	Lengths []float64 `json:"lengths"`
+
+	Candidates []Candidate `json:"candidates"`
+	Patterns   []Pattern   `json:"patterns"`
+
+	// Next[a][b] is the number of valid ballots ranking b immediately after a,
+	// and not equal to it
+	Next [][]float64 `json:"next"`
+}
+
+type Candidate struct {
+	Index     int    `json:"index"`
+	Name      string `json:"name"`
+	Withdrawn bool   `json:"withdrawn"`
+	// FirstPreferences counts the ballots whose first choice among the candidates
+	// that aren't withdrawn is this candidate, as in the first round of the count
+	FirstPreferences float64 `json:"first_preferences"`
+	Share            float64 `json:"share"`
+	// Positions[k] is the number of ballots ranking the candidate in position k+1,
+	// candidates ranked equally sharing a position
+	Positions []float64 `json:"positions"`
+	// Ranked is the number of ballots ranking the candidate anywhere
+	Ranked float64 `json:"ranked"`
+}
+
+// Pattern is a ranking, and the number of ballots that cast exactly it.
+type Pattern struct {
+	Preferences []int    `json:"preferences"`
+	Equal       []bool   `json:"equal,omitempty"`
+	Names       []string `json:"names"`
+	Ballots     float64  `json:"ballots"`
+	Share       float64  `json:"share"`
+}
+
+// Pair is two candidates ranked consecutively, in either order, on Ballots ballots.
+type Pair struct {
+	A, B    int
+	Ballots float64
+}
+
+// Compute returns the statistics of the ballots of e.
+func Compute(e *election.Election, opts Options) *Report {
+	if opts.Patterns <= 0 {
+		opts.Patterns = DefaultPatterns
+	}
+
+	r := &Report{
+		Title:      e.Title,
+		Seats:      e.Seats,
+		Lengths:    []float64{0},
+		Candidates: make([]Candidate, e.Candidates),
+		Next:       make([][]float64, e.Candidates),
+	}
+	for i := range r.Candidates {
+		r.Candidates[i] = Candidate{
+			Index:     i,
+			Name:      e.CandidateNames[i],
+			Withdrawn: e.Withdrawn[i],
+			Positions: make([]float64, 0),
+		}
+		r.Next[i] = make([]float64, e.Candidates)
+	}
+
+	patterns := make(map[string]*Pattern)
+	ranked := 0.0
+	for _, b := range e.Ballots {
+		w := b.Weight.Float64()
+		r.Ballots += w
+		switch {
+		case b.IsEmpty():
+			r.Blank += w
+			continue
+		case b.AllWithdrawn(e.Withdrawn):
+			r.AllWithdrawn += w
+			continue
+		}
+		r.Valid += w
+
+		n := len(b.Preferences)
+		for len(r.Lengths) <= n {
+			r.Lengths = append(r.Lengths, 0)
+		}
+		r.Lengths[n] += w
+		ranked += float64(n) * w
+
+		first := true
+		for position, group := range b.Groups() {
+			// the first preference is split among the candidates of the first group
+			// with any that aren't withdrawn, as in the first round of the count
+			continuing := 0
+			for _, c := range group {
+				if !e.Withdrawn[c] {
+					continuing++
+				}
+			}
+			for _, c := range group {
+				cand := &r.Candidates[c]
+				for len(cand.Positions) <= position {
+					cand.Positions = append(cand.Positions, 0)
+				}
+				cand.Positions[position] += w
+				cand.Ranked += w
+				if first && !e.Withdrawn[c] {
+					cand.FirstPreferences += w / float64(continuing)
+				}
+			}
+			first = first && continuing == 0
+		}
+		for k := 1; k < len(b.Preferences); k++ {
+			if k >= len(b.Equal) || !b.Equal[k] {
+				r.Next[b.Preferences[k-1]][b.Preferences[k]] += w
+			}
+		}
+
+		key := patternKey(b)
+		p, ok := patterns[key]
+		if !ok {
+			p = &Pattern{Preferences: b.Preferences}
+			if !b.Strict() {
+				p.Equal = b.Equal
+			}
+			patterns[key] = p
+		}
+		p.Ballots += w
+	}
+
+	if r.Valid > 0 {
+		r.AverageLength = ranked / r.Valid
+		r.MedianLength = median(r.Lengths, r.Valid)
+		for i := range r.Candidates {
+			r.Candidates[i].Share = r.Candidates[i].FirstPreferences / r.Valid
+		}
+	}
+	r.Patterns = topPatterns(patterns, opts.Patterns, r.Valid, e.CandidateNames)
+	return r
+}
+
+// median returns the weighted median of the histogram h, which counts total values:
+// the average of the lowest value with at least half of the total at or below it,
+// and of the lowest value with more than half of the total at or below it
+func median(h []float64, total float64) float64 {
+	at := func(half float64, strict bool) int {
+		cumulative := 0.0
+		for n, count := range h {
+			cumulative += count
+			if cumulative > half || !strict && cumulative == half {
+				return n
+			}
+		}
+		return len(h) - 1
+	}
+	return float64(at(total/2, false)+at(total/2, true)) / 2
+}
+
+func patternKey(bl election.Ballot) string {
+	var b strings.Builder
+	for k, c := range bl.Preferences {
+		if k < len(bl.Equal) && bl.Equal[k] {
+			b.WriteByte('=')
+		}
+		b.WriteString(strconv.Itoa(c))
+		b.WriteByte(' ')
+	}
+	return b.String()
+}
+
+// topPatterns returns the n most common patterns, ties ordered by ranking
+func topPatterns(patterns map[string]*Pattern, n int, valid float64, names []string) []Pattern {
+	out := make([]Pattern, 0, len(patterns))
+	for _, p := range patterns {
+		out = append(out, *p)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Ballots != out[j].Ballots {
+			return out[i].Ballots > out[j].Ballots
+		}
+		if !slices.Equal(out[i].Preferences, out[j].Preferences) {
+			return lessRanking(out[i].Preferences, out[j].Preferences)
+		}
+		return lessEqual(out[i].Equal, out[j].Equal)
+	})
+	if len(out) > n {
+		out = out[:n]
+	}
+	for i := range out {
+		out[i].Names = make([]string, len(out[i].Preferences))
+		for k, c := range out[i].Preferences {
+			out[i].Names[k] = names[c]
+		}
+		if valid > 0 {
+			out[i].Share = out[i].Ballots / valid
+		}
+	}
+	return out
+}
+
+func lessRanking(a, b []int) bool {
+	for k := 0; k < len(a) && k < len(b); k++ {
+		if a[k] != b[k] {
+			return a[k] < b[k]
+		}
+	}
+	return len(a) < len(b)
+}
+
+// lessEqual orders the same preferences by their equal rankings, the strict ballot first
+func lessEqual(a, b []bool) bool {
+	for k := 0; k < len(a) || k < len(b); k++ {
+		x, y := k < len(a) && a[k], k < len(b) && b[k]
+		if x != y {
+			return y
+		}
+	}
+	return false
+}
+
+// Affinity returns how many ballots rank a and b consecutively, in either order.
+func (r *Report) Affinity(a, b int) float64 {
+	if a == b {
+		return 0
+	}
+	return r.Next[a][b] + r.Next[b][a]
+}
+
+// Pairs returns the pairs of candidates ranked consecutively on at least one ballot,
+// by descending number of ballots.
+func (r *Report) Pairs() []Pair {
+	var out []Pair
+	for a := range r.Next {
+		for b := a + 1; b < len(r.Next); b++ {
+			if n := r.Affinity(a, b); n > 0 {
+				out = append(out, Pair{A: a, B: b, Ballots: n})
+			}
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		return out[i].Ballots > out[j].Ballots
+	})
+	return out
+}
+
+func (r *Report) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
@@ -0,0 +1,169 @@
+package stats
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/linuxfoundation-it/meek-stv/election"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompute(t *testing.T) {
+	e := &election.Election{
+		Title:          "Stats",
+		Candidates:     3,
+		Seats:          1,
+		Withdrawn:      map[int]bool{2: true},
+		CandidateNames: []string{"A", "B", "C"},
+		Ballots: []election.Ballot{
+			{Weight: election.NewWeight(4), Preferences: []int{0, 1}},
+			{Weight: election.NewWeight(3), Preferences: []int{1}},
+			{Weight: election.NewWeight(2), Preferences: []int{2, 1, 0}},
+			{Weight: election.NewWeight(1), Preferences: []int{}},
+			{Weight: election.NewWeight(5), Preferences: []int{2}},
+		},
+	}
+
+	r := Compute(e, Options{})
+	assert.Equal(t, 15.0, r.Ballots)
+	assert.Equal(t, 9.0, r.Valid)
+	assert.Equal(t, 1.0, r.Blank)
+	assert.Equal(t, 5.0, r.AllWithdrawn)
+	assert.Equal(t, e.CountEmpty().Float64(), r.Blank+r.AllWithdrawn)
+
+	assert.Equal(t, []float64{0, 3, 4, 2}, r.Lengths)
+	assert.InDelta(t, float64(4*2+3+2*3)/9, r.AverageLength, 1e-12)
+	assert.Equal(t, 2.0, r.MedianLength)
+
+	// C is withdrawn, so B is the first choice of the third ballot
+	assert.Equal(t, 4.0, r.Candidates[0].FirstPreferences)
+	assert.Equal(t, 5.0, r.Candidates[1].FirstPreferences)
+	assert.Equal(t, 0.0, r.Candidates[2].FirstPreferences)
+	assert.InDelta(t, 5.0/9, r.Candidates[1].Share, 1e-12)
+
+	assert.Equal(t, []float64{4, 0, 2}, r.Candidates[0].Positions)
+	assert.Equal(t, []float64{3, 6}, r.Candidates[1].Positions)
+	assert.Equal(t, 9.0, r.Candidates[1].Ranked)
+
+	require.Len(t, r.Patterns, 3)
+	assert.Equal(t, []string{"A", "B"}, r.Patterns[0].Names)
+	assert.Equal(t, 4.0, r.Patterns[0].Ballots)
+	assert.Equal(t, []int{1}, r.Patterns[1].Preferences)
+
+	assert.Equal(t, 4.0, r.Next[0][1])
+	assert.Equal(t, 2.0, r.Next[1][0])
+	assert.Equal(t, 6.0, r.Affinity(0, 1))
+	assert.Equal(t, 2.0, r.Affinity(1, 2))
+	assert.Equal(t, []Pair{{A: 0, B: 1, Ballots: 6}, {A: 1, B: 2, Ballots: 2}}, r.Pairs())
+}
+
+func TestCompute_Patterns(t *testing.T) {
+	e := readElection(t, "../testdata/election13.txt")
+	r := Compute(e, Options{Patterns: 3})
+	require.Len(t, r.Patterns, 3)
+	for i := 1; i < len(r.Patterns); i++ {
+		assert.GreaterOrEqual(t, r.Patterns[i-1].Ballots, r.Patterns[i].Ballots)
+	}
+
+	first := 0.0
+	for _, c := range r.Candidates {
+		first += c.FirstPreferences
+	}
+	assert.Equal(t, r.Valid, first)
+	assert.Equal(t, e.CountEmpty().Float64(), r.Blank+r.AllWithdrawn)
+}
+
+func TestCompute_FractionalWeights(t *testing.T) {
+	half, err := election.ParseWeight("0.5")
+	require.NoError(t, err)
+	e := &election.Election{
+		Title:          "Weighted",
+		Candidates:     2,
+		Seats:          1,
+		CandidateNames: []string{"A", "B"},
+		Ballots: []election.Ballot{
+			{Weight: half, Preferences: []int{0}},
+			{Weight: election.NewWeight(1), Preferences: []int{1, 0}},
+		},
+	}
+	r := Compute(e, Options{})
+	assert.Equal(t, 1.5, r.Valid)
+	assert.InDelta(t, 1.0/3, r.Candidates[0].Share, 1e-12)
+	assert.Equal(t, 2.0, r.MedianLength)
+
+	var b bytes.Buffer
+	require.NoError(t, r.WriteText(&b))
+	assert.Contains(t, b.String(), "0.5")
+}
+
+func TestCompute_Empty(t *testing.T) {
+	e := &election.Election{Title: "Empty", Candidates: 2, Seats: 1, CandidateNames: []string{"A", "B"}}
+	r := Compute(e, Options{})
+	assert.Zero(t, r.Valid)
+	assert.Zero(t, r.MedianLength)
+	assert.Empty(t, r.Patterns)
+
+	var b bytes.Buffer
+	require.NoError(t, r.WriteText(&b))
+	assert.NotContains(t, b.String(), "NaN")
+}
+
+func TestWrite(t *testing.T) {
+	e := readElection(t, "../testdata/election12.txt")
+	r := Compute(e, Options{})
+
+	var b bytes.Buffer
+	require.NoError(t, r.WriteJSON(&b))
+	var decoded Report
+	require.NoError(t, json.Unmarshal(b.Bytes(), &decoded))
+	assert.Equal(t, r, &decoded)
+
+	b.Reset()
+	require.NoError(t, r.WriteText(&b))
+	assert.Contains(t, b.String(), "First preferences")
+	assert.Contains(t, b.String(), "Yvette (withdrawn)")
+}
+
+func readElection(t *testing.T, path string) *election.Election {
+	t.Helper()
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+
+	e, err := election.Parse(f)
+	require.NoError(t, err)
+	e.Unquote()
+	return e
+}
+
+func TestCompute_EqualRankings(t *testing.T) {
+	e := &election.Election{
+		Title:          "Equal",
+		Candidates:     3,
+		Seats:          1,
+		Withdrawn:      map[int]bool{2: true},
+		CandidateNames: []string{"A", "B", "C"},
+		Ballots: []election.Ballot{
+			{Weight: election.NewWeight(4), Preferences: []int{2, 0, 1}, Equal: []bool{false, true, false}},
+			{Weight: election.NewWeight(2), Preferences: []int{0, 1}, Equal: []bool{false, true}},
+			{Weight: election.NewWeight(1), Preferences: []int{0, 1}},
+		},
+	}
+	r := Compute(e, Options{})
+
+	// C is withdrawn, so A takes the first preference of the C = A ballots whole
+	assert.Equal(t, 6.0, r.Candidates[0].FirstPreferences)
+	assert.Equal(t, 1.0, r.Candidates[1].FirstPreferences)
+	assert.Equal(t, []float64{7}, r.Candidates[0].Positions)
+	assert.Equal(t, []float64{2, 5}, r.Candidates[1].Positions)
+	assert.Equal(t, 5.0, r.Next[0][1])
+
+	require.Len(t, r.Patterns, 3)
+	assert.Equal(t, []bool{false, true, false}, r.Patterns[0].Equal)
+	assert.Equal(t, "C = A > B", r.Patterns[0].ranking())
+	assert.Equal(t, "A = B", r.Patterns[1].ranking())
+	assert.Nil(t, r.Patterns[2].Equal)
+}
@@ -0,0 +1,110 @@
+package stats
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+// WriteText writes the report as plain text tables.
+func (r *Report) WriteText(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+
+	fmt.Fprintf(tw, "%s\n\n", r.Title)
+	fmt.Fprintf(tw, "ballots\t%s\t\n", ballots(r.Ballots))
+	fmt.Fprintf(tw, "valid\t%s\t\n", ballots(r.Valid))
+	fmt.Fprintf(tw, "blank\t%s\t\n", ballots(r.Blank))
+	fmt.Fprintf(tw, "only withdrawn candidates\t%s\t\n", ballots(r.AllWithdrawn))
+	fmt.Fprintf(tw, "average ranking length\t%.2f\t\n", r.AverageLength)
+	fmt.Fprintf(tw, "median ranking length\t%.1f\t\n", r.MedianLength)
+
+	fmt.Fprintf(tw, "\nFirst preferences\n")
+	fmt.Fprintf(tw, "#\tcandidate\tballots\tshare\t\n")
+	for _, c := range r.Candidates {
+		name := c.Name
+		if c.Withdrawn {
+			name += " (withdrawn)"
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t\n", c.Index+1, name, ballots(c.FirstPreferences), percent(c.Share))
+	}
+
+	fmt.Fprintf(tw, "\nRanking lengths\n")
+	fmt.Fprintf(tw, "ranked\tballots\tshare\t\n")
+	for n, count := range r.Lengths {
+		if n == 0 {
+			continue
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%s\t\n", n, ballots(count), percent(share(count, r.Valid)))
+	}
+
+	positions := 0
+	for _, c := range r.Candidates {
+		positions = max(positions, len(c.Positions))
+	}
+	fmt.Fprintf(tw, "\nRank positions\n")
+	fmt.Fprintf(tw, "candidate\t")
+	for k := 1; k <= positions; k++ {
+		fmt.Fprintf(tw, "%d\t", k)
+	}
+	fmt.Fprintf(tw, "ranked\t\n")
+	for _, c := range r.Candidates {
+		fmt.Fprintf(tw, "%s\t", c.Name)
+		for k := 0; k < positions; k++ {
+			n := 0.0
+			if k < len(c.Positions) {
+				n = c.Positions[k]
+			}
+			fmt.Fprintf(tw, "%s\t", ballots(n))
+		}
+		fmt.Fprintf(tw, "%s\t\n", ballots(c.Ranked))
+	}
+
+	fmt.Fprintf(tw, "\nMost common ballots\n")
+	fmt.Fprintf(tw, "ballots\tshare\tranking\t\n")
+	for _, p := range r.Patterns {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t\n", ballots(p.Ballots), percent(p.Share), p.ranking())
+	}
+
+	fmt.Fprintf(tw, "\nRanked consecutively\n")
+	fmt.Fprintf(tw, "ballots\tcandidates\t\n")
+	for _, p := range r.Pairs() {
+		fmt.Fprintf(tw, "%s\t%s, %s\t\n", ballots(p.Ballots), r.Candidates[p.A].Name, r.Candidates[p.B].Name)
+	}
+
+	return tw.Flush()
+}
+
+// ranking returns the names of the pattern, candidates ranked equally joined by " = "
+func (p Pattern) ranking() string {
+	var sb strings.Builder
+	for k, name := range p.Names {
+		switch {
+		case k == 0:
+		case k < len(p.Equal) && p.Equal[k]:
+			sb.WriteString(" = ")
+		default:
+			sb.WriteString(" > ")
+		}
+		sb.WriteString(name)
+	}
+	return sb.String()
+}
+
+func share(n, total float64) float64 {
+	if total == 0 {
+		return 0
+	}
+	return n / total
+}
+
+// ballots formats a number of ballots, without the rounding errors of adding fractional weights
+func ballots(n float64) string {
+	return strconv.FormatFloat(math.Round(n*1e6)/1e6, 'f', -1, 64)
+}
+
+func percent(f float64) string {
+	return fmt.Sprintf("%.2f%%", f*100)
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"fmt"
+)
+
+func validateCmd(args []string, env *env) int {
+	fs := newFlagSet("validate", "[ballot file]", "Checks that a ballot file is well formed and warns about suspicious ballots.", env)
+	input := addInputFlags(fs)
+
+	positional, err := parseFlags(fs, args)
+	if err != nil {
+		return usageError(err)
+	}
+	if len(positional) > 1 {
+		fs.Usage()
+		return exitUsage
+	}
+
+	e, code, err := input.read(first(positional), env.stdout, env)
+	if err != nil {
+		fmt.Fprintf(env.stdout, "invalid: %v\n", err)
+		return code
+	}
+
+	for _, w := range e.Warnings() {
+		fmt.Fprintf(env.stdout, "warning: %s\n", w)
+	}
+
+	fmt.Fprintf(env.stdout, "valid: %d candidates, %d seats, %d ballot lines\n", e.Candidates, e.Seats, len(e.Ballots))
+	return exitOK
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/linuxfoundation-it/meek-stv/opavote"
+)
+
+func verifyCmd(args []string, env *env) int {
+	fs := newFlagSet("verify", "<ballot file> <OpaVote results JSON>",
+		"Recounts the ballots with the options of the OpaVote results and reports where the two counts disagree.\n"+
+			"Exits with status 1 if they disagree.", env)
+	input := addInputFlags(fs)
+	tolVotes := fs.Float64("tol-votes", opavote.DefaultTolerance.Votes, "accepted difference in candidate votes")
+	tolThreshold := fs.Float64("tol-threshold", opavote.DefaultTolerance.Threshold, "accepted difference in threshold")
+	tolExhausted := fs.Float64("tol-exhausted", opavote.DefaultTolerance.Exhausted, "accepted difference in exhausted votes")
+
+	positional, err := parseFlags(fs, args)
+	if err != nil {
+		return usageError(err)
+	}
+	if len(positional) != 2 {
+		fs.Usage()
+		return exitUsage
+	}
+
+	e, code, err := input.read(positional[0], env.stderr, env)
+	if err != nil {
+		fmt.Fprintln(env.stderr, err)
+		return code
+	}
+	want, err := opavote.LoadFile(positional[1])
+	if err != nil {
+		fmt.Fprintln(env.stderr, err)
+		return exitFailure
+	}
+
+	mismatches, err := opavote.Verify(want, e, opavote.Tolerance{
+		Votes:     *tolVotes,
+		Threshold: *tolThreshold,
+		Exhausted: *tolExhausted,
+	})
+	if err != nil {
+		fmt.Fprintln(env.stderr, err)
+		return exitFailure
+	}
+
+	if len(mismatches) == 0 {
+		fmt.Fprintf(env.stdout, "%s: the count matches OpaVote in all %d rounds\n", want.Title, len(want.Rounds))
+		return exitOK
+	}
+	fmt.Fprintf(env.stdout, "%s: %d differences with OpaVote\n", want.Title, len(mismatches))
+	for _, m := range mismatches {
+		fmt.Fprintln(env.stdout, m)
+	}
+	return exitFailure
+}
@@ -0,0 +1,40 @@
+// Runs the WebAssembly build under Node, and checks the functions it exposes.
+// usage: node run.mjs <wasm_exec.js> <meekstv.wasm> <OpaVote ballot file>
+import assert from "node:assert/strict";
+import { readFileSync } from "node:fs";
+import { createRequire } from "node:module";
+
+const [wasmExec, wasm, ballotFile] = process.argv.slice(2);
+createRequire(import.meta.url)(wasmExec);
+
+const go = new Go();
+const { instance } = await WebAssembly.instantiate(readFileSync(wasm), go.importObject);
+go.run(instance);
+
+const ballots = readFileSync(ballotFile, "utf8");
+
+const parsed = meekstv.parse(ballots);
+assert.equal(parsed.error, undefined);
+const summary = JSON.parse(parsed.result);
+assert.equal(summary.candidates.length, 6);
+
+const counted = meekstv.count(ballots, { seats: 3, withdrawn: [0] });
+assert.equal(counted.error, undefined);
+const result = JSON.parse(counted.result);
+assert.equal(result.seats, 3);
+assert.equal(result.winners.length, 3);
+assert.ok(!result.winners.some((w) => w.index === 0));
+
+// options can also be passed as JSON
+assert.deepEqual(JSON.parse(meekstv.count(ballots, '{"seats": 3, "withdrawn": [0]}').result), result);
+
+const page = meekstv.render(ballots, { format: "html" });
+assert.ok(page.result.startsWith("<!DOCTYPE html>"));
+const table = meekstv.render(ballots, { format: "table", precision: 0 });
+assert.match(table.result, /Threshold/);
+
+assert.match(meekstv.count("not a ballot file").error, /invalid number/);
+assert.match(meekstv.render(ballots, { format: "pdf" }).error, /unknown format/);
+
+console.log("ok");
+process.exit(0);
@@ -0,0 +1,173 @@
+// Package webapi implements the functions that the WebAssembly build exposes to JavaScript.
+// They take and return strings, so that they run and are tested on any platform.
+package webapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/linuxfoundation-it/meek-stv/election"
+	"github.com/linuxfoundation-it/meek-stv/htmlreport"
+	"github.com/linuxfoundation-it/meek-stv/meekstv"
+)
+
+// Options are the options of a count, as a JSON object.
+type Options struct {
+	// Seats overrides the number of seats of the ballots when positive
+	Seats int `json:"seats"`
+	// Withdrawn lists the 0-based indices of candidates to withdraw, in addition to those of the ballots
+	Withdrawn []int `json:"withdrawn"`
+	// Format is the format of Render: plain, table, markdown or html. Defaults to plain.
+	Format string `json:"format"`
+	// Order is the order of candidates and transfers of Render: index or amount. Defaults to index.
+	Order string `json:"order"`
+	// Precision is the number of decimals of Render. Defaults to meekstv.DefaultRenderOptions.Precision.
+	Precision *int `json:"precision"`
+}
+
+type Summary struct {
+	Title      string          `json:"title"`
+	Seats      int             `json:"seats"`
+	Candidates []string        `json:"candidates"`
+	Withdrawn  []int           `json:"withdrawn"`
+	Lines      int             `json:"lines"`
+	Ballots    election.Weight `json:"ballots"`
+	Empty      election.Weight `json:"empty"`
+	Warnings   []string        `json:"warnings"`
+}
+
+type Result struct {
+	Title   string            `json:"title"`
+	Seats   int               `json:"seats"`
+	Winners []meekstv.Winner  `json:"winners"`
+	Log     *meekstv.Document `json:"log"`
+}
+
+// Parse reads ballots, an OpaVote ballot file or an election in the JSON ballot format,
+// and returns a JSON Summary of it.
+func Parse(ballots string) (string, error) {
+	e, err := read(ballots)
+	if err != nil {
+		return "", err
+	}
+
+	s := Summary{
+		Title:      e.Title,
+		Seats:      e.Seats,
+		Candidates: e.CandidateNames,
+		Withdrawn:  make([]int, 0),
+		Lines:      len(e.Ballots),
+		Ballots:    e.TotalWeight(),
+		Empty:      e.CountEmpty(),
+		Warnings:   e.Warnings(),
+	}
+	for i := 0; i < e.Candidates; i++ {
+		if e.Withdrawn[i] {
+			s.Withdrawn = append(s.Withdrawn, i)
+		}
+	}
+	if s.Warnings == nil {
+		s.Warnings = []string{}
+	}
+	return marshal(s)
+}
+
+// Count counts ballots with the JSON options and returns the JSON Result.
+func Count(ballots, options string) (string, error) {
+	e, _, err := prepare(ballots, options)
+	if err != nil {
+		return "", err
+	}
+
+	l := meekstv.Count(e)
+	res := Result{
+		Title:   e.Title,
+		Seats:   e.Seats,
+		Winners: l.Elected(),
+		Log:     l.Document(),
+	}
+	return marshal(res)
+}
+
+// Render counts ballots with the JSON options and returns the report in the format of the options.
+func Render(ballots, options string) (string, error) {
+	e, opts, err := prepare(ballots, options)
+	if err != nil {
+		return "", err
+	}
+
+	ropts := meekstv.DefaultRenderOptions
+	if opts.Precision != nil {
+		if *opts.Precision < 0 {
+			return "", fmt.Errorf("negative precision %d", *opts.Precision)
+		}
+		ropts.Precision = *opts.Precision
+	}
+	switch opts.Order {
+	case "", "index":
+		ropts.Order = meekstv.ByIndex
+	case "amount":
+		ropts.Order = meekstv.ByAmount
+	default:
+		return "", fmt.Errorf("unknown order %q", opts.Order)
+	}
+
+	l := meekstv.Count(e)
+	var b strings.Builder
+	switch opts.Format {
+	case "html":
+		err = htmlreport.Write(&b, &l, e, htmlreport.Options{Precision: ropts.Precision})
+	case "":
+		opts.Format = "plain"
+		fallthrough
+	default:
+		var r meekstv.Renderer
+		if r, err = meekstv.NewRenderer(opts.Format, ropts); err == nil {
+			err = r.Render(&b, &l)
+		}
+	}
+	if err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+func read(ballots string) (*election.Election, error) {
+	if strings.HasPrefix(strings.TrimSpace(ballots), "{") {
+		return election.ReadJSON(strings.NewReader(ballots))
+	}
+	e, err := election.Parse(strings.NewReader(ballots))
+	if err != nil {
+		return nil, err
+	}
+	e.Unquote()
+	return e, nil
+}
+
+// prepare reads the ballots and applies the options
+func prepare(ballots, options string) (*election.Election, *Options, error) {
+	opts := &Options{}
+	if strings.TrimSpace(options) != "" {
+		if err := json.Unmarshal([]byte(options), opts); err != nil {
+			return nil, nil, fmt.Errorf("invalid options: %w", err)
+		}
+	}
+
+	e, err := read(ballots)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := e.Override(opts.Seats, opts.Withdrawn); err != nil {
+		return nil, nil, err
+	}
+	return e, opts, nil
+}
+
+func marshal(v any) (string, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
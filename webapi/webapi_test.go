@@ -0,0 +1,121 @@
+package webapi
+
+import (
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/linuxfoundation-it/meek-stv/election"
+	"github.com/linuxfoundation-it/meek-stv/meekstv"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func ballots(t *testing.T) string {
+	t.Helper()
+	b, err := os.ReadFile("../testdata/election13.txt")
+	require.NoError(t, err)
+	return string(b)
+}
+
+func TestParse(t *testing.T) {
+	out, err := Parse(ballots(t))
+	require.NoError(t, err)
+
+	var s Summary
+	require.NoError(t, json.Unmarshal([]byte(out), &s))
+	assert.Equal(t, "Stack Overflow Moderator Election 2021", s.Title)
+	assert.Equal(t, 2, s.Seats)
+	assert.Len(t, s.Candidates, 6)
+	assert.Equal(t, []int{2}, s.Withdrawn)
+	assert.Equal(t, election.NewWeight(28331), s.Ballots)
+	assert.Equal(t, election.NewWeight(376), s.Empty)
+
+	_, err = Parse("2 1\n1 3 0\n0\n")
+	assert.Error(t, err)
+}
+
+func TestCount(t *testing.T) {
+	out, err := Count(ballots(t), `{"seats": 3, "withdrawn": [0]}`)
+	require.NoError(t, err)
+
+	var res Result
+	require.NoError(t, json.Unmarshal([]byte(out), &res))
+	assert.Equal(t, 3, res.Seats)
+	assert.Len(t, res.Winners, 3)
+	assert.NotContains(t, res.Winners, meekstv.Winner{Index: 0, Name: "Zoe"})
+
+	// JSON ballots, without options
+	out, err = Count(`{"title": "T", "seats": 1, "candidates": ["A", "B"], "ballots": [{"weight": 2, "preferences": [1]}]}`, "")
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal([]byte(out), &res))
+	assert.Equal(t, []meekstv.Winner{{Index: 1, Name: "B"}}, res.Winners)
+
+	for _, options := range []string{`{"seats": -1}`, `{"withdrawn": [6]}`, `{`} {
+		_, err = Count(ballots(t), options)
+		assert.Error(t, err, options)
+	}
+
+	// fewer seats than the classes reserve
+	classes := `{"title": "T", "seats": 2, "candidates": ["A", "B"], "classes": [{"name": "X", "seats": 2, "candidates": [0, 1]}],
+		"ballots": [{"weight": 2, "preferences": [1]}]}`
+	_, err = Count(classes, `{"seats": 1}`)
+	assert.ErrorContains(t, err, "seat classes reserve")
+}
+
+func TestRender(t *testing.T) {
+	out, err := Render(ballots(t), "")
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(out, "Round 0:\n"))
+
+	out, err = Render(ballots(t), `{"format": "markdown", "precision": 0, "order": "amount"}`)
+	require.NoError(t, err)
+	assert.Contains(t, out, "| Zoe | 1 | 7236 |")
+
+	out, err = Render(ballots(t), `{"format": "html"}`)
+	require.NoError(t, err)
+	assert.Contains(t, out, "<h1>Stack Overflow Moderator Election 2021</h1>")
+
+	for _, options := range []string{`{"format": "pdf"}`, `{"order": "random"}`, `{"precision": -1}`} {
+		_, err = Render(ballots(t), options)
+		assert.Error(t, err, options)
+	}
+}
+
+// TestWasm builds the WebAssembly binary and runs testdata/run.mjs against it with Node.
+func TestWasm(t *testing.T) {
+	if testing.Short() {
+		t.Skip("builds the WebAssembly binary")
+	}
+	node, err := exec.LookPath("node")
+	if err != nil {
+		t.Skip("node is not installed")
+	}
+	goroot, err := exec.Command("go", "env", "GOROOT").Output()
+	require.NoError(t, err)
+
+	var wasmExec string
+	for _, dir := range []string{"lib/wasm", "misc/wasm"} {
+		path := filepath.Join(strings.TrimSpace(string(goroot)), dir, "wasm_exec.js")
+		if _, err := os.Stat(path); err == nil {
+			wasmExec = path
+			break
+		}
+	}
+	if wasmExec == "" {
+		t.Skip("wasm_exec.js not found")
+	}
+
+	wasm := filepath.Join(t.TempDir(), "meekstv.wasm")
+	build := exec.Command("go", "build", "-o", wasm, "../cmd/wasm")
+	build.Env = append(os.Environ(), "GOOS=js", "GOARCH=wasm")
+	out, err := build.CombinedOutput()
+	require.NoError(t, err, string(out))
+
+	out, err = exec.Command(node, "testdata/run.mjs", wasmExec, wasm, "../testdata/election13.txt").CombinedOutput()
+	require.NoError(t, err, string(out))
+	assert.Equal(t, "ok\n", string(out))
+}